@@ -1,11 +1,14 @@
-package banner
-
-import "fmt"
-
-func PrintBanner() {
-	fmt.Println("XSSRecon")
-}
-
-func PrintVersion() {
-	fmt.Println("1.0.0")
-}
+package banner
+
+import "fmt"
+
+// Version is the current release of xssrecon.
+const Version = "1.0.0"
+
+func PrintBanner() {
+	fmt.Println("XSSRecon")
+}
+
+func PrintVersion() {
+	fmt.Println(Version)
+}
@@ -0,0 +1,168 @@
+package scanner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// analyzeGroup holds the recorded responses for one injection point.
+type analyzeGroup struct {
+	base   *warcResponse
+	probes map[string]*warcResponse
+}
+
+// Analyze classifies the probe responses recorded with --record again,
+// using the current character set and matcher, and reports them as a scan
+// would. Only characters that were probed during the recording can be
+// classified, and browser (DOM) checks are not recorded.
+func (s *Scanner) Analyze(path string) error {
+	responses, err := readWARC(path)
+	if err != nil {
+		return err
+	}
+
+	groups := make(map[string]*analyzeGroup)
+	var order []string
+	for i := range responses {
+		r := &responses[i]
+		key := r.Input + "\x00" + r.Param
+		g, ok := groups[key]
+		if !ok {
+			g = &analyzeGroup{probes: make(map[string]*warcResponse)}
+			groups[key] = g
+			order = append(order, key)
+		}
+		if g.base == nil && s.isCanary(r.Payload) {
+			g.base = r
+			continue
+		}
+		// Later probes with the same payload come from the optional probe
+		// modes; the first is the character probe
+		if char, ok := s.probeChar(r.Payload); ok && g.probes[char] == nil {
+			g.probes[char] = r
+		}
+	}
+
+	// Concurrent scans interleave inputs; report each input's injection
+	// points together, in the order the inputs were first seen
+	inputOrder := make(map[string]int)
+	for _, key := range order {
+		input, _, _ := strings.Cut(key, "\x00")
+		if _, ok := inputOrder[input]; !ok {
+			inputOrder[input] = len(inputOrder)
+		}
+	}
+	slices.SortStableFunc(order, func(a, b string) int {
+		inputA, _, _ := strings.Cut(a, "\x00")
+		inputB, _, _ := strings.Cut(b, "\x00")
+		return inputOrder[inputA] - inputOrder[inputB]
+	})
+
+	lastInput := ""
+	for _, key := range order {
+		g := groups[key]
+		if g.base == nil {
+			continue
+		}
+		if g.base.Input != lastInput && !s.opts.JSONOutput {
+			if s.opts.NoColor {
+				fmt.Printf("\nPROCESSING: %s\n", g.base.Input)
+			} else {
+				fmt.Printf("\n\033[96mPROCESSING: %s\033[0m\n", g.base.Input)
+			}
+		}
+		lastInput = g.base.Input
+		s.analyzeInjectionPoint(g)
+	}
+	return nil
+}
+
+func (s *Scanner) analyzeInjectionPoint(g *analyzeGroup) {
+	base := g.base
+	canary := base.Payload
+	output := JSONOutput{
+		Processing:  base.Input,
+		BaseURL:     base.URL,
+		Param:       base.Param,
+		Canary:      canary,
+		Fingerprint: Fingerprint(base.Input, base.Param, "http"),
+		StatusCode:  base.StatusCode,
+	}
+	if !s.opts.JSONOutput {
+		s.printBaseURL(fmt.Sprintf("%s [%s]", base.URL, base.Param))
+	}
+
+	body := s.reflectionBody(base.Body, base.Decoded, canary)
+	if !s.reflects(body, canary) {
+		s.printReflected(false)
+		s.printJSON(output)
+		return
+	}
+	output.Reflected = true
+	s.printReflected(true)
+	output.Contexts = s.reflectionContexts(body, canary)
+	s.printContexts(output.Contexts)
+	output.ReflectionCount = len(s.matcher.Match(body, canary))
+	output.Occurrences = s.occurrences(body, canary)
+	s.printOccurrences(output.Occurrences)
+
+	output.Allowed, output.Blocked, output.Stripped, output.Converted = []string{}, []string{}, []string{}, []string{}
+	for _, char := range s.chars {
+		probe := g.probes[char]
+		if probe == nil {
+			continue
+		}
+		probeCanary := strings.TrimSuffix(probe.Payload, char)
+		switch verdict, form := s.classifyChar(probe.Body, probeCanary, char); verdict {
+		case "allowed":
+			output.Allowed = append(output.Allowed, char)
+			s.explainChar("allowed", char, probeCanary, probeCanary+char, probe.Body)
+		case "converted":
+			output.Converted = append(output.Converted, fmt.Sprintf("%s ➔ %s", char, form.text))
+			if output.ConvertedEncodings == nil {
+				output.ConvertedEncodings = make(map[string]string)
+			}
+			output.ConvertedEncodings[char] = form.encoding
+			s.explainChar("converted", char, probeCanary, probeCanary+form.text, probe.Body)
+		case "stripped":
+			output.Stripped = append(output.Stripped, char)
+			s.explainChar("stripped", char, probeCanary, probeCanary, probe.Body)
+		default:
+			output.Blocked = append(output.Blocked, char)
+			s.explainChar("blocked", char, probeCanary, probeCanary, probe.Body)
+		}
+	}
+	output.Count = map[string]int{
+		"allowed":   len(output.Allowed),
+		"blocked":   len(output.Blocked),
+		"stripped":  len(output.Stripped),
+		"converted": len(output.Converted),
+	}
+	s.printCharResults(&output)
+	s.printJSON(output)
+}
+
+// probeAlphabet is every character that may follow a canary in a probe.
+func (s *Scanner) probeAlphabet() []string {
+	return slices.Concat(specialChars, extendedChars, s.chars)
+}
+
+// isCanary reports whether a recorded payload is a bare canary.
+func (s *Scanner) isCanary(payload string) bool {
+	return validateCanary(payload, s.probeAlphabet()) == nil
+}
+
+// probeChar returns the character a recorded payload probed for, if it is
+// canary+char for a character in the current set. Longer probes win, so a
+// "</" probe is not mistaken for "/".
+func (s *Scanner) probeChar(payload string) (string, bool) {
+	chars := slices.Clone(s.chars)
+	slices.SortStableFunc(chars, func(a, b string) int { return len(b) - len(a) })
+	for _, char := range chars {
+		if prefix, ok := strings.CutSuffix(payload, char); ok && s.isCanary(prefix) {
+			return char, true
+		}
+	}
+	return "", false
+}
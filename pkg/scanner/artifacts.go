@@ -0,0 +1,163 @@
+package scanner
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ArtifactEntry describes a single saved artifact in a host's index.json.
+type ArtifactEntry struct {
+	URL     string    `json:"url"`
+	Param   string    `json:"param"`
+	Kind    string    `json:"kind"`
+	File    string    `json:"file"`
+	SavedAt time.Time `json:"saved_at"`
+}
+
+// ArtifactStore saves evidence under <dir>/<host>/<param>/ and keeps an
+// index.json per host so results can be followed up without grepping.
+// Indexes are merged with the entries of earlier runs into the same
+// directory.
+type ArtifactStore struct {
+	dir     string
+	mu      sync.Mutex
+	indexes map[string][]ArtifactEntry
+}
+
+// NewArtifactStore saves evidence under dir.
+func NewArtifactStore(dir string) (*ArtifactStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating artifacts directory: %w", err)
+	}
+	return &ArtifactStore{
+		dir:     dir,
+		indexes: make(map[string][]ArtifactEntry),
+	}, nil
+}
+
+// Save writes data for the given target URL and parameter and records it in
+// the host index. name is used as the file name inside the parameter
+// directory, with a short hash of the URL path inserted before its
+// extension so the same parameter on different paths is kept apart.
+func (a *ArtifactStore) Save(targetURL, param, kind, name string, data []byte) (string, error) {
+	host, path := "unknown", ""
+	if u, err := url.Parse(targetURL); err == nil && u.Host != "" {
+		host, path = u.Host, u.Path
+	}
+	sum := sha256.Sum256([]byte(path))
+	ext := filepath.Ext(name)
+	name = strings.TrimSuffix(name, ext) + "-" + hex.EncodeToString(sum[:4]) + ext
+	hostDir := sanitizePathComponent(host)
+	rel := filepath.Join(hostDir, sanitizePathComponent(param), sanitizePathComponent(name))
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	index, err := a.index(hostDir)
+	if err != nil {
+		return "", err
+	}
+
+	full := filepath.Join(a.dir, rel)
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(full, data, 0o644); err != nil {
+		return "", err
+	}
+
+	// A rewritten file replaces its old entry
+	file := filepath.ToSlash(rel)
+	index = slices.DeleteFunc(index, func(e ArtifactEntry) bool { return e.File == file })
+	index = append(index, ArtifactEntry{
+		URL:     targetURL,
+		Param:   param,
+		Kind:    kind,
+		File:    file,
+		SavedAt: time.Now().UTC(),
+	})
+	a.indexes[hostDir] = index
+	indexBytes, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(a.dir, hostDir, "index.json"), indexBytes, 0o644); err != nil {
+		return "", err
+	}
+	return full, nil
+}
+
+// index returns the entries of a host's index.json, read from disk the
+// first time the host is seen.
+func (a *ArtifactStore) index(hostDir string) ([]ArtifactEntry, error) {
+	if index, ok := a.indexes[hostDir]; ok {
+		return index, nil
+	}
+	var index []ArtifactEntry
+	data, err := os.ReadFile(filepath.Join(a.dir, hostDir, "index.json"))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(data, &index); err != nil {
+			return nil, fmt.Errorf("reading %s/index.json: %w", hostDir, err)
+		}
+	}
+	a.indexes[hostDir] = index
+	return index, nil
+}
+
+// httpExchange writes the request that resp answered and resp itself as
+// they went over the wire. reqBody is only written when no redirect was
+// followed, since redirected requests are sent without it.
+func httpExchange(resp *response, reqBody string) []byte {
+	var b bytes.Buffer
+	if req := resp.Request; req != nil {
+		fmt.Fprintf(&b, "%s %s HTTP/1.1\r\nHost: %s\r\n", req.Method, req.URL.RequestURI(), req.URL.Host)
+		req.Header.Write(&b)
+		b.WriteString("\r\n")
+		if len(resp.Redirects) == 0 && reqBody != "" {
+			b.WriteString(reqBody + "\r\n\r\n")
+		}
+	}
+	fmt.Fprintf(&b, "HTTP/1.1 %d %s\r\n", resp.StatusCode, http.StatusText(resp.StatusCode))
+	resp.Header.Write(&b)
+	b.WriteString("\r\n")
+	b.WriteString(resp.Body)
+	return b.Bytes()
+}
+
+// sanitizePathComponent makes s safe to use as a single path element.
+func sanitizePathComponent(s string) string {
+	if s == "" {
+		return "_"
+	}
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', '{', '}':
+			return '_'
+		}
+		if r < 0x20 {
+			return '_'
+		}
+		return r
+	}, s)
+	if s == "." || s == ".." {
+		return "_"
+	}
+	return s
+}
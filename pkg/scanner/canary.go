@@ -0,0 +1,164 @@
+package scanner
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+)
+
+// legacyCanary is the fixed marker of earlier releases, kept behind
+// --legacy-canary for tooling that matches on it.
+const legacyCanary = "rix4uni"
+
+const canaryAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+// randomCanary returns a fresh lowercase alphanumeric marker. It starts with
+// a letter so it is also a valid identifier wherever it is reflected.
+func randomCanary() string {
+	b := make([]byte, 8)
+	b[0] = canaryAlphabet[rand.N(26)]
+	for i := 1; i < len(b); i++ {
+		b[i] = canaryAlphabet[rand.N(len(canaryAlphabet))]
+	}
+	return string(b)
+}
+
+// validateCanary rejects custom canaries that could not be told apart from
+// the probes appended to them.
+func validateCanary(canary string, chars []string) error {
+	if canary == "" {
+		return fmt.Errorf("canary must not be empty")
+	}
+	if strings.IndexFunc(canary, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) >= 0 {
+		return fmt.Errorf("canary %q must not contain whitespace or control characters", canary)
+	}
+	for _, char := range chars {
+		if strings.Contains(canary, char) {
+			return fmt.Errorf("canary %q must not contain the probed character %s", canary, char)
+		}
+	}
+	return nil
+}
+
+// newCanary returns the marker for the next probe against param of
+// targetURL. Without unique canaries this is the run's canary, picked at
+// random at startup unless set with --canary. With
+// unique canaries enabled every probe gets its own marker of the form
+// xk<paramhash><counter>k, so a reflection can always be attributed to the
+// exact parameter and probe that produced it. The trailing "k" keeps one
+// canary from being a prefix of another (xk..5k vs xk..51k). Either is
+// preceded by the host's page prefix when --page-canary learned one.
+func (s *Scanner) newCanary(targetURL, param string) string {
+	prefix := s.canaryPrefix(targetURL)
+	if !s.opts.UniqueCanaries {
+		return prefix + s.canary
+	}
+	h := fnv.New32a()
+	h.Write([]byte(param))
+	return fmt.Sprintf("%sxk%06x%dk", prefix, h.Sum32()&0xffffff, s.probeCounter.Add(1))
+}
+
+// classAttr matches class attribute values in a page.
+var classAttr = regexp.MustCompile(`(?i)\sclass\s*=\s*["']([^"']+)["']`)
+
+// pagePrefix returns the most common CSS class prefix on a page, such as
+// "btn-" for a page full of btn-primary and btn-lg, or "" if none is used
+// more than once. Ties go to the alphabetically first prefix so the choice
+// is stable across runs.
+func pagePrefix(body string) string {
+	counts := make(map[string]int)
+	for _, m := range classAttr.FindAllStringSubmatch(body, -1) {
+		for _, class := range strings.Fields(m[1]) {
+			i := strings.IndexAny(class, "-_")
+			if i < 2 || i > 8 || i == len(class)-1 {
+				continue
+			}
+			if strings.IndexFunc(class[:i], func(r rune) bool {
+				return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+			}) >= 0 {
+				continue
+			}
+			counts[class[:i+1]]++
+		}
+	}
+	prefixes := make([]string, 0, len(counts))
+	for p := range counts {
+		prefixes = append(prefixes, p)
+	}
+	sort.Strings(prefixes)
+	best := ""
+	for _, p := range prefixes {
+		if counts[p] > 1 && (best == "" || counts[p] > counts[best]) {
+			best = p
+		}
+	}
+	return best
+}
+
+// learnCanaryPrefix fetches the unmodified request once per host and
+// remembers its most common class prefix, so canaries sent to that host
+// look like the page's own class names. Hosts whose page yields no usable
+// prefix keep the plain canary.
+func (s *Scanner) learnCanaryPrefix(req *utils.Request) {
+	if !s.opts.PageCanary {
+		return
+	}
+	host := canaryHost(req.URL)
+	if _, ok := s.canaryPrefixes.Load(host); ok {
+		return
+	}
+	prefix := ""
+	if body, err := s.fetch(s.originalTarget(req)); err == nil {
+		prefix = pagePrefix(body)
+	} else if s.opts.Verbose {
+		fmt.Printf("Error fetching page for canary prefix: %v\n", err)
+	}
+	if prefix != "" && validateCanary(prefix+s.canary, s.chars) != nil {
+		prefix = ""
+	}
+	if _, loaded := s.canaryPrefixes.LoadOrStore(host, prefix); !loaded && s.opts.Verbose && prefix != "" {
+		fmt.Printf("Using page canary prefix %q for %s\n", prefix, host)
+	}
+}
+
+// canaryPrefix returns the page prefix learned for targetURL's host.
+func (s *Scanner) canaryPrefix(targetURL string) string {
+	if p, ok := s.canaryPrefixes.Load(canaryHost(targetURL)); ok {
+		return p.(string)
+	}
+	return ""
+}
+
+func canaryHost(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		return u.Host
+	}
+	return rawURL
+}
+
+// probeTarget regenerates the targets for req with payload and returns the
+// one covering param.
+func (s *Scanner) probeTarget(req *utils.Request, param, payload string) (utils.Target, bool) {
+	return s.probeTargetEncoded(req, param, payload, s.opts.EncodePayload)
+}
+
+// probeTargetEncoded is probeTarget with an explicit query encoding mode.
+func (s *Scanner) probeTargetEncoded(req *utils.Request, param, payload string, mode utils.EncodeMode) (utils.Target, bool) {
+	targets, err := s.generateTargetsEncoded(req, payload, mode)
+	if err != nil {
+		return utils.Target{}, false
+	}
+	for _, t := range targets {
+		if t.Param == param {
+			return t, true
+		}
+	}
+	return utils.Target{}, false
+}
@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+)
+
+// caseKeywords are the tags, handlers and schemes that blacklist filters
+// most often match, probed by --case-mutation.
+var caseKeywords = []string{"<script", "<img", "<svg", "<iframe", "onerror=", "onload=", "javascript:", "alert("}
+
+// mixCase alternates the case of the letters in s: "<script" becomes
+// "<ScRiPt".
+func mixCase(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if unicode.IsLetter(r) {
+			if upper {
+				r = unicode.ToUpper(r)
+			} else {
+				r = unicode.ToLower(r)
+			}
+			upper = !upper
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// caseMutationProbe sends each keyword in lowercase and, when that does not
+// come back unmodified, in mixed case. Keywords that only survive mixed case
+// are reported: the filter is case-sensitive and trivially bypassed.
+func (s *Scanner) caseMutationProbe(req *utils.Request, target utils.Target, reflectedInDOM bool) []string {
+	mutated := []string{}
+	for _, keyword := range caseKeywords {
+		survived := false
+		for _, variant := range []string{keyword, mixCase(keyword)} {
+			s.resetState(req)
+			canary := s.newCanary(target.URL, target.Param)
+			testTarget, ok := s.probeTarget(req, target.Param, canary+variant)
+			if !ok {
+				break
+			}
+
+			var body string
+			var err error
+			if reflectedInDOM {
+				body, err = s.getDOM(testTarget)
+			} else {
+				body, err = s.fetch(testTarget)
+			}
+			if err != nil {
+				break
+			}
+			if survived = s.reflectsWith(body, canary, variant); survived {
+				if variant != keyword {
+					mutated = append(mutated, keyword)
+				}
+				break
+			}
+		}
+	}
+	return mutated
+}
@@ -0,0 +1,72 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// ParseChars splits a --chars value into one probe per character.
+func ParseChars(spec string) []string {
+	var chars []string
+	for _, r := range spec {
+		if !slices.Contains(chars, string(r)) {
+			chars = append(chars, string(r))
+		}
+	}
+	return chars
+}
+
+// printableChars renders space and line-break probes by name for the
+// console, including inside "char ➔ conversion" entries.
+func printableChars(list []string) []string {
+	out := make([]string, len(list))
+	for i, item := range list {
+		if item == " " || strings.HasPrefix(item, "  ➔ ") {
+			item = "SPACE" + item[1:]
+		}
+		out[i] = strings.NewReplacer("\n", "LF", "\t", "TAB").Replace(item)
+	}
+	return out
+}
+
+// LoadCharsFile reads probes from a file, one per line. Lines may hold
+// multi-character sequences such as "</"; blank lines and lines starting
+// with "#" are skipped. Surrounding whitespace is trimmed, so a literal
+// space or tab is written as \s or \t.
+func LoadCharsFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening chars file: %w", err)
+	}
+	defer f.Close()
+
+	var chars []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		switch line {
+		case `\s`:
+			line = " "
+		case `\t`:
+			line = "\t"
+		case `\n`:
+			line = "\n"
+		}
+		if !slices.Contains(chars, line) {
+			chars = append(chars, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading chars file: %w", err)
+	}
+	if len(chars) == 0 {
+		return nil, fmt.Errorf("chars file %s has no probes", path)
+	}
+	return chars, nil
+}
@@ -0,0 +1,117 @@
+package scanner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+)
+
+// combinedQueryTargets splits targets into the plain query parameter
+// targets that --combine-params can cover with one request and the rest.
+func combinedQueryTargets(targets []utils.Target) (query, rest []utils.Target) {
+	for _, t := range targets {
+		if t.Param == utils.PlaceholderParam || strings.Contains(t.Param, ":") || t.Input == "" || !strings.Contains(t.Input, "?") {
+			rest = append(rest, t)
+			continue
+		}
+		query = append(query, t)
+	}
+	return query, rest
+}
+
+// combinedProbe sends one request with every query parameter set to its own
+// marker (canaryx0x, canaryx1x, ...) and returns the targets whose marker
+// came back, over HTTP or in the rendered DOM. The others are reported as
+// not reflected without a request of their own. With --keep-value each
+// marker is appended to the parameter's value, as the regular checks do.
+// When the combined request fails, every target is returned for the
+// regular checks.
+func (s *Scanner) combinedProbe(req *utils.Request, targets []utils.Target) []utils.Target {
+	if len(targets) < 2 {
+		return targets
+	}
+	canary := s.canaryPrefix(req.URL) + s.canary
+	var original map[string]string
+	if s.opts.KeepValue {
+		var err error
+		if original, err = utils.FirstQueryValues(req.URL); err != nil {
+			return targets
+		}
+	}
+	values := make(map[string]string, len(targets))
+	markers := make(map[string]string, len(targets))
+	for i, t := range targets {
+		markers[t.Param] = batchMarker(canary, i)
+		values[t.Param] = original[t.Param] + markers[t.Param]
+	}
+	combinedURL, err := utils.SetQueryValues(req.URL, values)
+	if err != nil {
+		return targets
+	}
+	combined := targets[0]
+	combined.URL = combinedURL
+	combined.Payload = ""
+
+	s.resetState(req)
+	resp, err := s.fetchResponse(combined)
+	if err != nil {
+		if s.opts.Verbose {
+			fmt.Printf("Error fetching combined URL: %v\n", err)
+		}
+		return targets
+	}
+	bodies := []string{resp.Body}
+	missing := func() bool {
+		for _, m := range markers {
+			if !s.reflectsAny(bodies, m) {
+				return true
+			}
+		}
+		return false
+	}
+	if missing() && isGet(combined) && domPointless(combinedURL, resp) == "" && s.domBudget.Take(utils.PlaceholderParam) {
+		if dom, err := s.getDOM(combined); err == nil {
+			bodies = append(bodies, dom)
+		}
+	}
+
+	// As in processBaseURL, dead endpoints do not count as negatives. The
+	// page is hashed without the markers, which change every scan
+	record := !slices.Contains(s.opts.SkipStatus, resp.StatusCode) && !(resp.StatusCode >= 400 && s.opts.SkipErrorPages)
+	unmarked := *resp
+	for _, m := range markers {
+		unmarked.Body = strings.ReplaceAll(unmarked.Body, m, "")
+	}
+	hash := pageHash(&unmarked)
+
+	var reflected []utils.Target
+	for _, t := range targets {
+		if s.reflectsAny(bodies, markers[t.Param]) {
+			reflected = append(reflected, t)
+			continue
+		}
+		if record {
+			s.hostCache.RecordParam(req.URL, t.Param, hash, false)
+		}
+		output := s.newOutput(req, combinedURL, t.Param, markers[t.Param])
+		output.StatusCode = resp.StatusCode
+		output.Fingerprint = Fingerprint(req.URL, t.Param, "http")
+		if !s.opts.JSONOutput {
+			s.printBaseURL(fmt.Sprintf("%s [%s]", combinedURL, t.Param))
+		}
+		s.printReflected(false)
+		s.printJSON(output)
+	}
+	return reflected
+}
+
+func (s *Scanner) reflectsAny(bodies []string, canary string) bool {
+	for _, body := range bodies {
+		if s.reflects(body, canary) {
+			return true
+		}
+	}
+	return false
+}
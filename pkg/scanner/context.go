@@ -0,0 +1,135 @@
+package scanner
+
+import (
+	"strings"
+)
+
+// Reflection contexts, the rough position in an HTML document at which a
+// reflection lands.
+const (
+	ctxHTML           = "html"
+	ctxComment        = "comment"
+	ctxRCDATA         = "rcdata"
+	ctxStyle          = "style"
+	ctxScript         = "script"
+	ctxScriptDouble   = "script-string-double"
+	ctxScriptSingle   = "script-string-single"
+	ctxScriptTemplate = "script-template"
+	ctxAttrDouble     = "attribute-double"
+	ctxAttrSingle     = "attribute-single"
+	ctxAttrUnquoted   = "attribute-unquoted"
+	ctxAttrURL        = "attribute-url"
+	ctxTag            = "tag"
+)
+
+var contextDescriptions = map[string]string{
+	ctxHTML:           "HTML text",
+	ctxComment:        "an HTML comment",
+	ctxRCDATA:         "RCDATA (textarea/title) text",
+	ctxStyle:          "a style block",
+	ctxScript:         "a script block",
+	ctxScriptDouble:   "a double-quoted script string",
+	ctxScriptSingle:   "a single-quoted script string",
+	ctxScriptTemplate: "a script template literal",
+	ctxAttrDouble:     "a double-quoted attribute value",
+	ctxAttrSingle:     "a single-quoted attribute value",
+	ctxAttrUnquoted:   "an unquoted attribute value",
+	ctxAttrURL:        "the start of a URL attribute value",
+	ctxTag:            "tag markup",
+}
+
+// htmlContext describes where in an HTML document offset off lies.
+func htmlContext(body string, off int) string {
+	return contextDescriptions[reflectionContext(body, off)]
+}
+
+// reflectionContext roughly classifies where in an HTML document offset off
+// lies, returning one of the ctx constants.
+func reflectionContext(body string, off int) string {
+	before := strings.ToLower(body[:off])
+	switch {
+	case strings.LastIndex(before, "<!--") > strings.LastIndex(before, "-->"):
+		return ctxComment
+	case strings.LastIndex(before, "<script") > strings.LastIndex(before, "</script"):
+		if lt, gt := strings.LastIndex(before, "<"), strings.LastIndex(before, ">"); lt > gt {
+			return tagContext(before[lt:])
+		}
+		open := strings.LastIndex(before, "<script")
+		return scriptContext(body[open+strings.Index(before[open:], ">")+1 : off])
+	case strings.LastIndex(before, "<style") > strings.LastIndex(before, "</style"):
+		return ctxStyle
+	case strings.LastIndex(before, "<textarea") > strings.LastIndex(before, "</textarea"),
+		strings.LastIndex(before, "<title") > strings.LastIndex(before, "</title"):
+		return ctxRCDATA
+	}
+	if lt, gt := strings.LastIndex(before, "<"), strings.LastIndex(before, ">"); lt > gt {
+		return tagContext(before[lt:])
+	}
+	return ctxHTML
+}
+
+// urlAttributes take a URL as their value, so a reflection at the start of
+// one can supply a javascript: URL.
+var urlAttributes = map[string]bool{
+	"href": true, "src": true, "action": true, "formaction": true, "data": true,
+	"poster": true, "background": true, "cite": true, "codebase": true, "xlink:href": true,
+}
+
+// tagContext classifies a position inside a tag given the tag text so far.
+func tagContext(tag string) string {
+	switch {
+	case strings.Count(tag, `"`)%2 == 1:
+		return urlValueContext(tag, strings.LastIndex(tag, `"`), ctxAttrDouble)
+	case strings.Count(tag, "'")%2 == 1:
+		return urlValueContext(tag, strings.LastIndex(tag, "'"), ctxAttrSingle)
+	case strings.HasSuffix(tag, "="):
+		return urlValueContext(tag, len(tag)-1, ctxAttrUnquoted)
+	}
+	return ctxTag
+}
+
+// urlValueContext returns ctxAttrURL when the attribute value opening at
+// tag[start] is empty so far and belongs to a URL attribute, and ctx
+// otherwise.
+func urlValueContext(tag string, start int, ctx string) string {
+	if strings.TrimSpace(tag[start+1:]) != "" {
+		return ctx
+	}
+	// The quote of a quoted value follows the "=", an unquoted value starts
+	// right after it
+	name := strings.TrimRight(tag[:start], " \t\n")
+	name = strings.TrimRight(strings.TrimSuffix(name, "="), " \t\n")
+	i := strings.LastIndexFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == ':')
+	})
+	if urlAttributes[name[i+1:]] {
+		return ctxAttrURL
+	}
+	return ctx
+}
+
+// scriptContext tells whether the end of script, the code of a script
+// block so far, is inside a string literal.
+func scriptContext(script string) string {
+	var quote byte
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\'' || c == '`'):
+			quote = c
+		}
+	}
+	switch quote {
+	case '"':
+		return ctxScriptDouble
+	case '\'':
+		return ctxScriptSingle
+	case '`':
+		return ctxScriptTemplate
+	}
+	return ctxScript
+}
@@ -0,0 +1,132 @@
+package scanner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+)
+
+// controlChars are the non-printable characters probed by --control-chars,
+// keyed by the name used in the results.
+var controlChars = []struct {
+	name string
+	char string
+}{
+	{"NUL", "\x00"},
+	{"TAB", "\t"},
+	{"LF", "\n"},
+	{"CR", "\r"},
+	{"CRLF", "\r\n"},
+	{"VT", "\v"},
+}
+
+// controlEscapes are the backslash escapes servers use for control
+// characters in JavaScript and JSON strings.
+var controlEscapes = map[string]string{
+	"\x00": `\0`,
+	"\t":   `\t`,
+	"\n":   `\n`,
+	"\r":   `\r`,
+	"\r\n": `\r\n`,
+	"\v":   `\v`,
+}
+
+// controlConversions lists the encoded forms a control character may come
+// back as: HTML entities and JavaScript escapes, backslash escapes,
+// percent-encoding, and <br> for line breaks.
+func controlConversions(char string) []string {
+	var forms []string
+	if len(char) == 1 {
+		for _, f := range conversionForms(char) {
+			forms = append(forms, f.text)
+		}
+	} else {
+		var entity strings.Builder
+		for _, r := range char {
+			fmt.Fprintf(&entity, "&#%d;", r)
+		}
+		forms = append(forms, entity.String())
+	}
+	if esc, ok := controlEscapes[char]; ok {
+		forms = append(forms, esc)
+	}
+	var pct strings.Builder
+	for i := 0; i < len(char); i++ {
+		fmt.Fprintf(&pct, "%%%02x", char[i])
+	}
+	forms = append(forms, pct.String(), strings.ToUpper(pct.String()))
+	if strings.Contains(char, "\n") {
+		forms = append(forms, "<br>", "<br/>", "<br />")
+	}
+	return forms
+}
+
+// controlTail follows the control character in each probe so truncation and
+// splitting of the reflection can be told apart.
+const controlTail = "qz7tail"
+
+// controlProbe sends canary+char+controlTail for each control character and
+// classifies what happened to the reflection:
+//
+//	allowed    the character came back unmodified
+//	converted  the character came back encoded, e.g. "converted (&#10;)"
+//	stripped   the character was removed, the rest of the value kept
+//	split      the value came back in two parts with something in between
+//	truncated  the value was cut off at the character
+//	blocked    the canary did not come back at all
+func (s *Scanner) controlProbe(req *utils.Request, target utils.Target, reflectedInDOM bool) map[string]string {
+	results := make(map[string]string)
+	for _, c := range controlChars {
+		s.resetState(req)
+		canary := s.newCanary(target.URL, target.Param)
+		testTarget, ok := s.probeTarget(req, target.Param, canary+c.char+controlTail)
+		if !ok {
+			continue
+		}
+
+		var body string
+		var err error
+		if reflectedInDOM {
+			body, err = s.getDOM(testTarget)
+		} else {
+			body, err = s.fetch(testTarget)
+		}
+		if err != nil {
+			continue
+		}
+		results[c.name] = s.classifyControl(body, canary, c.char)
+	}
+	return results
+}
+
+// classifyControl finds the reflections of canary in body with the
+// configured matcher and classifies what followed them, preferring the
+// outcome that kept the most of the probe.
+func (s *Scanner) classifyControl(body, canary, char string) string {
+	spans := s.matcher.Match(body, canary)
+	if len(spans) == 0 {
+		return "blocked"
+	}
+	followedBy := func(suffix string) bool {
+		return slices.ContainsFunc(spans, func(span [2]int) bool {
+			return strings.HasPrefix(body[span[1]:], suffix)
+		})
+	}
+	if followedBy(char + controlTail) {
+		return "allowed"
+	}
+	for _, form := range controlConversions(char) {
+		if followedBy(form + controlTail) {
+			return "converted (" + form + ")"
+		}
+	}
+	switch {
+	case followedBy(controlTail):
+		return "stripped"
+	case strings.Contains(body[spans[0][1]:], controlTail):
+		return "split"
+	}
+	return "truncated"
+}
@@ -0,0 +1,145 @@
+package scanner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// namedEntities are the HTML named character references of the probed
+// characters, the most widely produced first.
+var namedEntities = map[rune][]string{
+	'\'': {"&apos;"},
+	'"':  {"&quot;", "&QUOT;"},
+	'<':  {"&lt;", "&LT;"},
+	'>':  {"&gt;", "&GT;"},
+	'&':  {"&amp;", "&AMP;"},
+	'(':  {"&lpar;"},
+	')':  {"&rpar;"},
+	'`':  {"&grave;", "&DiacriticalGrave;"},
+	'{':  {"&lcub;", "&lbrace;"},
+	'}':  {"&rcub;", "&rbrace;"},
+	'/':  {"&sol;"},
+	'\\': {"&bsol;"},
+	';':  {"&semi;"},
+	'=':  {"&equals;"},
+	':':  {"&colon;"},
+	'[':  {"&lsqb;", "&lbrack;"},
+	']':  {"&rsqb;", "&rbrack;"},
+	'%':  {"&percnt;"},
+	'\n': {"&NewLine;"},
+	'\t': {"&Tab;"},
+}
+
+// conversionForm is one way a server may encode a probed character: the
+// text as it appears in the response and the name of the encoding.
+type conversionForm struct {
+	text     string
+	encoding string
+}
+
+// conversionForms lists the encodings recognized for char: its HTML
+// entities, then the JavaScript escapes that matter when the reflection
+// lands inside a script block.
+func conversionForms(char string) []conversionForm {
+	return append(htmlEntities(char), jsEscapes(char)...)
+}
+
+// htmlEntities returns every HTML character reference of a single-character
+// probe: named, decimal (also zero-padded, as in &#039;) and hex in either
+// case. Forms ending in ";" come first so that &lt; is not reported as the
+// semicolon-less &lt, which browsers also decode.
+func htmlEntities(char string) []conversionForm {
+	r, size := utf8.DecodeRuneInString(char)
+	if size != len(char) || r == utf8.RuneError {
+		return nil
+	}
+	var forms []conversionForm
+	add := func(text, encoding string) {
+		for _, f := range forms {
+			if f.text == text {
+				return
+			}
+		}
+		forms = append(forms, conversionForm{text, encoding})
+	}
+	for _, name := range namedEntities[r] {
+		add(name, "html-named")
+	}
+	add(fmt.Sprintf("&#%d;", r), "html-decimal")
+	add(fmt.Sprintf("&#%03d;", r), "html-decimal")
+	add(fmt.Sprintf("&#x%x;", r), "html-hex")
+	add(fmt.Sprintf("&#x%X;", r), "html-hex")
+	add(fmt.Sprintf("&#x%02x;", r), "html-hex")
+	add(fmt.Sprintf("&#x%02X;", r), "html-hex")
+	add(fmt.Sprintf("&#X%X;", r), "html-hex")
+
+	// The same references without the trailing semicolon
+	for _, f := range slices.Clone(forms) {
+		add(strings.TrimSuffix(f.text, ";"), f.encoding+"-nosemi")
+	}
+	return forms
+}
+
+// jsEscapes returns the backslash escape of quotes, slashes and
+// backslashes as written by JSON and JavaScript string encoders, and the
+// \uXXXX, \xXX and octal \NNN escapes of a single-character probe, in
+// lower- and uppercase hex.
+func jsEscapes(char string) []conversionForm {
+	r, size := utf8.DecodeRuneInString(char)
+	if size != len(char) || r == utf8.RuneError || r > 0xffff {
+		return nil
+	}
+	var forms []conversionForm
+	if strings.ContainsRune(`"'\/`, r) {
+		forms = append(forms, conversionForm{`\` + char, "js-backslash-escape"})
+	}
+	add := func(format, encoding string) {
+		lower := fmt.Sprintf(format, r)
+		forms = append(forms, conversionForm{lower, encoding})
+		if upper := lower[:2] + strings.ToUpper(lower[2:]); upper != lower {
+			forms = append(forms, conversionForm{upper, encoding})
+		}
+	}
+	add(`\u%04x`, "js-unicode-escape")
+	if r <= 0xff {
+		add(`\x%02x`, "js-hex-escape")
+		forms = append(forms, conversionForm{fmt.Sprintf(`\%03o`, r), "js-octal-escape"})
+	}
+	return forms
+}
+
+// classifyChar decides whether the probe canary+char came back allowed,
+// converted (with the form observed) or blocked.
+func (s *Scanner) classifyChar(body, canary, char string) (string, conversionForm) {
+	forms := conversionForms(char)
+	for _, span := range s.matcher.Match(body, canary) {
+		rest := body[span[1]:]
+		if strings.HasPrefix(rest, char) && !startsWithForm(rest, char, forms) {
+			return "allowed", conversionForm{}
+		}
+	}
+	for _, f := range forms {
+		if s.reflectsWith(body, canary, f.text) {
+			return "converted", f
+		}
+	}
+	// The value came back without the character: filtered out rather than
+	// the request being rejected
+	if s.reflects(body, canary) {
+		return "stripped", conversionForm{}
+	}
+	return "blocked", conversionForm{}
+}
+
+// startsWithForm reports whether rest begins with a conversion of char that
+// itself starts with char, as "&amp;" does for "&" and "\" for "\".
+func startsWithForm(rest, char string, forms []conversionForm) bool {
+	for _, f := range forms {
+		if strings.HasPrefix(f.text, char) && strings.HasPrefix(rest, f.text) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+)
+
+// parseCookieHeader parses a "name=value; name2=value2" string as passed to --cookie.
+func parseCookieHeader(header string) ([]*http.Cookie, error) {
+	cookies, err := http.ParseCookie(header)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cookie string: %w", err)
+	}
+	return cookies, nil
+}
+
+// loadNetscapeCookies reads a Netscape cookies.txt file into the jar.
+func loadNetscapeCookies(path string, jar http.CookieJar) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening cookie file: %w", err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+		httpOnly := false
+		if strings.HasPrefix(line, "#HttpOnly_") {
+			line = strings.TrimPrefix(line, "#HttpOnly_")
+			httpOnly = true
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 7 {
+			return fmt.Errorf("cookie file %s:%d: expected 7 tab-separated fields", path, lineNo)
+		}
+		domain, includeSubdomains, path, secure, expiry, name, value := fields[0], fields[1], fields[2], fields[3], fields[4], fields[5], fields[6]
+
+		cookie := &http.Cookie{
+			Name:     name,
+			Value:    value,
+			Path:     path,
+			Secure:   strings.EqualFold(secure, "TRUE"),
+			HttpOnly: httpOnly,
+		}
+		if strings.EqualFold(includeSubdomains, "TRUE") {
+			cookie.Domain = domain
+		}
+		if exp, err := strconv.ParseInt(expiry, 10, 64); err == nil && exp > 0 {
+			cookie.Expires = time.Unix(exp, 0)
+		}
+
+		scheme := "http"
+		if cookie.Secure {
+			scheme = "https"
+		}
+		u := &url.URL{Scheme: scheme, Host: strings.TrimPrefix(domain, "."), Path: "/"}
+		jar.SetCookies(u, []*http.Cookie{cookie})
+	}
+	return sc.Err()
+}
+
+// requestCookies returns the cookies of a request template by name, or nil
+// if it has none or they cannot be parsed.
+func requestCookies(req *utils.Request) map[string]string {
+	if req.Cookie == "" {
+		return nil
+	}
+	parsed, err := parseCookieHeader(req.Cookie)
+	if err != nil {
+		return nil
+	}
+	cookies := make(map[string]string, len(parsed))
+	for _, c := range parsed {
+		cookies[c.Name] = c.Value
+	}
+	return cookies
+}
+
+// cookiesFor returns the cookies to send to targetURL: those stored in jar
+// followed by the --cookie values, with any values in overrides replacing
+// the cookie of the same name or, for new names, appended.
+func cookiesFor(jar http.CookieJar, extra []*http.Cookie, targetURL string, overrides map[string]string) []*http.Cookie {
+	var cookies []*http.Cookie
+	if jar != nil {
+		if u, err := url.Parse(targetURL); err == nil {
+			cookies = append(cookies, jar.Cookies(u)...)
+		}
+	}
+	cookies = append(cookies, extra...)
+
+	if len(overrides) == 0 {
+		return cookies
+	}
+	result := make([]*http.Cookie, 0, len(cookies)+len(overrides))
+	seen := make(map[string]bool, len(cookies))
+	for _, c := range cookies {
+		if v, ok := overrides[c.Name]; ok {
+			c = &http.Cookie{Name: c.Name, Value: v}
+		}
+		seen[c.Name] = true
+		result = append(result, c)
+	}
+
+	// Overrides for cookies not already sent are added, in a stable order
+	var names []string
+	for name := range overrides {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result = append(result, &http.Cookie{Name: name, Value: overrides[name]})
+	}
+	return result
+}
+
+// storingJar is the HTTP client's view of the shared cookie jar: it stores
+// the cookies responses set, but adds none to requests, since fetch sends
+// the jar's cookies itself so that injected values can replace them.
+type storingJar struct {
+	http.CookieJar
+}
+
+func (storingJar) Cookies(*url.URL) []*http.Cookie {
+	return nil
+}
+
+// addJarCookies adds to a redirected request the cookies of jar it does not
+// carry yet, such as those the redirect response set or replaced.
+func addJarCookies(req *http.Request, jar http.CookieJar) {
+	for _, c := range jar.Cookies(req.URL) {
+		if _, err := req.Cookie(c.Name); err != nil {
+			req.AddCookie(c)
+		}
+	}
+}
+
+// cookieHeader joins cookies into a Cookie header value. Unlike
+// http.Request.AddCookie it keeps values verbatim, so injected special
+// characters reach the server unmodified.
+func cookieHeader(cookies []*http.Cookie) string {
+	parts := make([]string, 0, len(cookies))
+	for _, c := range cookies {
+		parts = append(parts, c.Name+"="+c.Value)
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,149 @@
+package scanner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// CSPPolicy is one Content-Security-Policy of a response, parsed into its
+// directives, together with the weaknesses that let an injected script run
+// despite it.
+type CSPPolicy struct {
+	// Directives maps each directive name, lowercased, to its source list.
+	Directives map[string][]string `json:"directives"`
+	// ReportOnly is set for Content-Security-Policy-Report-Only policies,
+	// which browsers do not enforce.
+	ReportOnly bool `json:"report_only,omitempty"`
+	// Weaknesses describe how the policy can be trivially bypassed.
+	Weaknesses []string `json:"weaknesses,omitempty"`
+}
+
+// Bypassable reports whether the policy does not stop an injected script.
+func (p CSPPolicy) Bypassable() bool {
+	return len(p.Weaknesses) > 0
+}
+
+// parseCSP returns every policy sent in resp, enforced ones first. Policies
+// separated by commas, as when the header is repeated, are returned one by
+// one since browsers enforce each of them.
+func parseCSP(resp *response) []CSPPolicy {
+	var policies []CSPPolicy
+	for _, h := range []string{"Content-Security-Policy", "Content-Security-Policy-Report-Only"} {
+		for _, value := range resp.Header.Values(h) {
+			for _, policy := range strings.Split(value, ",") {
+				directives := parseCSPDirectives(policy)
+				if len(directives) == 0 {
+					continue
+				}
+				p := CSPPolicy{Directives: directives, ReportOnly: strings.HasSuffix(h, "Report-Only")}
+				p.Weaknesses = cspWeaknesses(p)
+				policies = append(policies, p)
+			}
+		}
+	}
+	return policies
+}
+
+// parseCSPDirectives splits a serialized policy into its directives. As in
+// browsers, names are case-insensitive and only the first occurrence of a
+// directive counts.
+func parseCSPDirectives(policy string) map[string][]string {
+	directives := make(map[string][]string)
+	for _, d := range strings.Split(policy, ";") {
+		fields := strings.Fields(d)
+		if len(fields) == 0 {
+			continue
+		}
+		name := strings.ToLower(fields[0])
+		if _, seen := directives[name]; seen {
+			continue
+		}
+		directives[name] = append([]string{}, fields[1:]...)
+	}
+	return directives
+}
+
+// cspSources returns the source list that governs directive, falling back
+// to default-src, and whether either is set.
+func cspSources(p CSPPolicy, directive string) ([]string, bool) {
+	if sources, ok := p.Directives[directive]; ok {
+		return sources, true
+	}
+	sources, ok := p.Directives["default-src"]
+	return sources, ok
+}
+
+// cspWeaknesses lists the trivial bypasses of a policy: scripts allowed
+// inline, from any host or from data: URLs, plugins left unrestricted, and
+// a policy that is only reported.
+func cspWeaknesses(p CSPPolicy) []string {
+	var weaknesses []string
+	if p.ReportOnly {
+		weaknesses = append(weaknesses, "report-only: the policy is not enforced")
+	}
+
+	scripts, ok := cspSources(p, "script-src")
+	if !ok {
+		weaknesses = append(weaknesses, "no script-src or default-src: scripts are unrestricted")
+	} else {
+		has := func(source string) bool {
+			return slices.ContainsFunc(scripts, func(s string) bool { return strings.EqualFold(s, source) })
+		}
+		// A nonce or hash makes browsers ignore 'unsafe-inline'
+		pinned := slices.ContainsFunc(scripts, func(s string) bool {
+			s = strings.ToLower(s)
+			return strings.HasPrefix(s, "'nonce-") || strings.HasPrefix(s, "'sha256-") || strings.HasPrefix(s, "'sha384-") || strings.HasPrefix(s, "'sha512-")
+		})
+		if has("'unsafe-inline'") && !pinned {
+			weaknesses = append(weaknesses, "script-src allows 'unsafe-inline'")
+		}
+		if !has("'strict-dynamic'") {
+			for _, wildcard := range []string{"*", "http:", "https:", "data:"} {
+				if has(wildcard) {
+					weaknesses = append(weaknesses, fmt.Sprintf("script-src allows %s", wildcard))
+				}
+			}
+		}
+	}
+
+	objects, ok := cspSources(p, "object-src")
+	switch {
+	case !ok:
+		weaknesses = append(weaknesses, "missing object-src: plugins are unrestricted")
+	case slices.Contains(objects, "*"):
+		weaknesses = append(weaknesses, "object-src allows *")
+	}
+	return weaknesses
+}
+
+// printCSP prints whether the response is protected by a policy that an
+// injected script cannot trivially get around. Every enforced policy
+// applies, so the response is only bypassable if all of them are.
+func (s *Scanner) printCSP(policies []CSPPolicy) {
+	if s.opts.JSONOutput {
+		return
+	}
+	var enforced []CSPPolicy
+	var weaknesses []string
+	for _, p := range policies {
+		if !p.ReportOnly {
+			enforced = append(enforced, p)
+			weaknesses = append(weaknesses, p.Weaknesses...)
+		}
+	}
+	line := "CSP: enforced"
+	switch {
+	case len(policies) == 0:
+		line = "CSP: none"
+	case len(enforced) == 0:
+		line = "CSP: report-only (not enforced)"
+	case !slices.ContainsFunc(enforced, func(p CSPPolicy) bool { return !p.Bypassable() }):
+		line = "CSP: bypassable (" + strings.Join(weaknesses, "; ") + ")"
+	}
+	if s.opts.NoColor {
+		fmt.Println(line)
+	} else {
+		fmt.Printf("\033[36m%s\033[0m\n", line)
+	}
+}
@@ -0,0 +1,64 @@
+package scanner
+
+import (
+	"fmt"
+
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+)
+
+// cstiProbes are the template expressions sent by --csti. Each evaluates to
+// 49 in the template language it targets.
+var cstiProbes = []string{
+	"{{7*7}}",    // AngularJS, Vue, Handlebars-style
+	"${7*7}",     // JavaScript template literals, JSP EL
+	"<%= 7*7 %>", // ERB, EJS
+}
+
+// cstiProbe sends canary+expression+controlTail for each template
+// expression and returns those that came back evaluated, as 49 between the
+// canary and the tail. Client-side templates only evaluate in a browser, so
+// GET targets are rendered, unless the host cache or the DOM budget rule the
+// browser out; others, or pages the browser fails to load, are checked in
+// the HTTP response, which still catches server-side evaluation. A target
+// that reflected in the DOM already holds a browser slot.
+func (s *Scanner) cstiProbe(req *utils.Request, target utils.Target, reflectedInDOM bool) []string {
+	useDOM := isGet(target)
+	if reason, skip := s.hostCache.SkipDOM(target.URL); useDOM && !reflectedInDOM && skip {
+		useDOM = false
+		if s.opts.Verbose && !s.opts.JSONOutput {
+			fmt.Printf("CSTI DOM CHECK SKIPPED: %s\n", reason)
+		}
+	}
+	if useDOM && !reflectedInDOM && !s.domBudget.Take(target.Param) {
+		useDOM = false
+		if s.opts.Verbose && !s.opts.JSONOutput {
+			fmt.Println("CSTI DOM CHECK SKIPPED: dom budget exhausted")
+		}
+	}
+
+	var evaluated []string
+	for _, expr := range cstiProbes {
+		s.resetState(req)
+		canary := s.newCanary(target.URL, target.Param)
+		testTarget, ok := s.probeTarget(req, target.Param, canary+expr+controlTail)
+		if !ok {
+			continue
+		}
+
+		var body string
+		var err error
+		if useDOM {
+			body, err = s.getDOM(testTarget)
+		}
+		if body == "" || err != nil {
+			body, err = s.fetch(testTarget)
+		}
+		if err != nil {
+			continue
+		}
+		if s.reflectsWith(body, canary, "49"+controlTail) {
+			evaluated = append(evaluated, expr)
+		}
+	}
+	return evaluated
+}
@@ -0,0 +1,152 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefectDojoExporter collects reflected findings during the scan and
+// uploads them to DefectDojo's import-scan API as a Generic Findings Import
+// when the scan finishes. The product and engagement are created when they
+// don't exist yet.
+type DefectDojoExporter struct {
+	baseURL    string
+	apiKey     string
+	product    string
+	engagement string
+	text       *reportText
+	client     *http.Client
+
+	mu       sync.Mutex
+	findings []defectDojoFinding
+}
+
+type defectDojoFinding struct {
+	Title          string   `json:"title"`
+	Description    string   `json:"description"`
+	Severity       string   `json:"severity"`
+	CWE            int      `json:"cwe"`
+	Date           string   `json:"date"`
+	Param          string   `json:"param,omitempty"`
+	Payload        string   `json:"payload,omitempty"`
+	Endpoints      []string `json:"endpoints"`
+	Mitigation     string   `json:"mitigation"`
+	UniqueID       string   `json:"unique_id_from_tool"`
+	Active         bool     `json:"active"`
+	Verified       bool     `json:"verified"`
+	DynamicFinding bool     `json:"dynamic_finding"`
+	StaticFinding  bool     `json:"static_finding"`
+}
+
+// NewDefectDojoExporter uploads findings to the DefectDojo instance at
+// baseURL with the given API key, with finding text in lang.
+func NewDefectDojoExporter(baseURL, apiKey, product, engagement, lang string) (*DefectDojoExporter, error) {
+	if apiKey == "" {
+		return nil, errors.New("--defectdojo-url requires --defectdojo-key")
+	}
+	if product == "" || engagement == "" {
+		return nil, errors.New("--defectdojo-url requires --defectdojo-product and --defectdojo-engagement")
+	}
+	text, err := reportLanguage(lang)
+	if err != nil {
+		return nil, err
+	}
+	return &DefectDojoExporter{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		product:    product,
+		engagement: engagement,
+		text:       text,
+		client:     &http.Client{Timeout: 2 * time.Minute},
+	}, nil
+}
+
+func (e *DefectDojoExporter) Write(output JSONOutput) error {
+	if !output.Reflected {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.findings = append(e.findings, defectDojoFinding{
+		Title:          findingTitle(output, e.text),
+		Description:    findingDescription(output, e.text),
+		Severity:       findingSeverity(output),
+		CWE:            79,
+		Date:           time.Now().Format("2006-01-02"),
+		Param:          output.Param,
+		Payload:        output.Canary + strings.Join(output.Allowed, ""),
+		Endpoints:      []string{output.BaseURL},
+		Mitigation:     e.text.Remediation,
+		UniqueID:       output.Fingerprint,
+		Active:         true,
+		DynamicFinding: true,
+	})
+	return nil
+}
+
+// Close uploads the collected findings. Nothing is sent when the scan found
+// no reflections, so an empty run doesn't close existing findings.
+func (e *DefectDojoExporter) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.findings) == 0 {
+		return nil
+	}
+
+	report, err := json.Marshal(map[string]any{"findings": e.findings})
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fields := map[string]string{
+		"scan_type":           "Generic Findings Import",
+		"product_name":        e.product,
+		"engagement_name":     e.engagement,
+		"auto_create_context": "true",
+		"active":              "true",
+		"verified":            "false",
+	}
+	for k, v := range fields {
+		if err := mw.WriteField(k, v); err != nil {
+			return err
+		}
+	}
+	part, err := mw.CreateFormFile("file", "xssrecon.json")
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(report); err != nil {
+		return err
+	}
+	if err := mw.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, e.baseURL+"/api/v2/import-scan/", &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Token "+e.apiKey)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("uploading to DefectDojo: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("DefectDojo import failed: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
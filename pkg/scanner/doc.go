@@ -0,0 +1,33 @@
+// Package scanner implements xssrecon's reflection checks: it injects a
+// canary into every injection point of a URL or request template, detects
+// where it is reflected, over HTTP or in the DOM rendered by headless
+// Chrome, and probes which special characters survive.
+//
+// The command-line tool is a thin wrapper around this package, so other
+// recon frameworks can use it as a library instead of running the binary;
+// see the package example.
+//
+// Every checked injection point produces one Result, handed to each
+// OutputWriter configured through Options. Scanner methods are safe for
+// concurrent use, so callers run their own worker pool around Scan or
+// ScanRequest. Progress is printed to standard output as in the command,
+// as JSON when Options.JSONOutput is set.
+//
+// # Stability
+//
+// The exported API of this package and of pkg/utils follows the semantic
+// version of the module (see banner.Version). Within a major version:
+//
+//   - exported identifiers are not removed or renamed and function
+//     signatures do not change;
+//   - new Options fields may be added, and their zero values keep the
+//     previous behavior, so Options should be built with field names;
+//   - new Result fields may be added, but the JSON names of existing ones
+//     do not change, so stored results and OutputWriter implementations
+//     keep working. The record format carries its own SchemaVersion,
+//     published as a JSON Schema by Schema;
+//   - OutputWriter does not gain methods.
+//
+// Unexported identifiers, the wording of text output and the set of probed
+// characters and payloads may change in any release.
+package scanner
@@ -0,0 +1,14 @@
+//go:build unix
+
+package scanner
+
+import "syscall"
+
+// openFileLimit returns the soft limit on open file descriptors.
+func openFileLimit() (uint64, bool) {
+	var rl syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rl); err != nil {
+		return 0, false
+	}
+	return uint64(rl.Cur), true
+}
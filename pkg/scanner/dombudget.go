@@ -0,0 +1,62 @@
+package scanner
+
+import (
+	"strings"
+	"sync"
+)
+
+// highValueParams are parameter names that commonly end up rendered in the
+// page or fed to client-side sinks, so they get first claim on the DOM budget.
+var highValueParams = map[string]bool{
+	"q": true, "s": true, "search": true, "query": true, "keyword": true, "term": true,
+	"redirect": true, "redirect_uri": true, "return": true, "returnurl": true, "next": true, "url": true,
+	"callback": true, "jsonp": true, "message": true, "msg": true, "error": true, "name": true,
+	"title": true, "lang": true, "ref": true, "hash": true,
+}
+
+// domBudgetReserve is the share of the DOM budget kept for high-value
+// parameters; other targets stop using the browser once only this much is left.
+const domBudgetReserve = 0.25
+
+// domBudget caps how many targets may fall back to the headless browser in
+// one run. Input is streamed, so prioritizing works by holding back a reserve
+// for high-value parameters instead of sorting the whole list.
+type domBudget struct {
+	limit   int
+	reserve int
+
+	mu   sync.Mutex
+	used int
+}
+
+func newDOMBudget(limit int) *domBudget {
+	if limit <= 0 {
+		return nil
+	}
+	return &domBudget{limit: limit, reserve: int(float64(limit) * domBudgetReserve)}
+}
+
+// Take claims a browser slot for a target probing param and reports whether
+// one was available.
+func (b *domBudget) Take(param string) bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	remaining := b.limit - b.used
+	if remaining <= 0 || (remaining <= b.reserve && !isHighValueParam(param)) {
+		return false
+	}
+	b.used++
+	return true
+}
+
+// isHighValueParam matches the bare name of param, ignoring injection point
+// prefixes such as "body:" or "header:".
+func isHighValueParam(param string) bool {
+	if i := strings.LastIndex(param, ":"); i != -1 {
+		param = param[i+1:]
+	}
+	return highValueParams[strings.ToLower(param)]
+}
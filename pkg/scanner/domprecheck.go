@@ -0,0 +1,50 @@
+package scanner
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// domPointless reports why rendering targetURL in the browser cannot reveal
+// a DOM reflection given its HTTP response, or "" if the fallback is worth
+// running. Each browser navigation costs seconds, so cheap signals from the
+// HTTP response are checked first.
+func domPointless(targetURL string, resp *response) string {
+	switch resp.StatusCode {
+	case http.StatusNotFound, http.StatusGone:
+		return "status " + http.StatusText(resp.StatusCode)
+	}
+	if strings.TrimSpace(resp.Body) == "" {
+		return "empty body"
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+			return "non-HTML content type " + mediaType
+		}
+	}
+	if len(resp.Redirects) > 0 && !sameSite(targetURL, resp.FinalURL) {
+		return "redirected to unrelated host"
+	}
+	return ""
+}
+
+// sameSite reports whether a and b share a registrable domain.
+func sameSite(a, b string) bool {
+	ua, errA := url.Parse(a)
+	ub, errB := url.Parse(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	ha, hb := strings.ToLower(ua.Hostname()), strings.ToLower(ub.Hostname())
+	if ha == hb {
+		return true
+	}
+	sa, errA := publicsuffix.EffectiveTLDPlusOne(ha)
+	sb, errB := publicsuffix.EffectiveTLDPlusOne(hb)
+	return errA == nil && errB == nil && sa == sb
+}
@@ -0,0 +1,90 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/chromedp"
+)
+
+// minDOMNeedle is the shortest canary worth searching a large DOM for;
+// shorter strings would match too many unrelated nodes.
+const minDOMNeedle = 6
+
+// maxDOMMatches caps how many matching nodes are serialized from one page.
+const maxDOMMatches = 50
+
+// domNeedle returns the canary at the start of an injected payload, the
+// part every reflection of it must contain.
+func domNeedle(payload string) string {
+	end := strings.IndexFunc(payload, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_')
+	})
+	if end == -1 {
+		end = len(payload)
+	}
+	if end < minDOMNeedle {
+		return ""
+	}
+	return payload[:end]
+}
+
+// serializeDOM returns the rendered HTML of the page. A DOM larger than
+// maxSize is never copied out of the browser in full: only the nodes that
+// contain needle are serialized, one per line, which is all reflection
+// checks look at.
+func serializeDOM(maxSize int64, needle string, html *string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		if maxSize <= 0 {
+			return chromedp.OuterHTML("html", html).Do(ctx)
+		}
+
+		// outerHTML.length counts UTF-16 units; the limit is in bytes
+		var size int64
+		if err := chromedp.Evaluate(`new Blob([document.documentElement.outerHTML]).size`, &size).Do(ctx); err != nil {
+			return err
+		}
+		if size <= maxSize {
+			return chromedp.OuterHTML("html", html).Do(ctx)
+		}
+		if needle == "" {
+			return fmt.Errorf("DOM of %d bytes exceeds --max-dom-size", size)
+		}
+
+		// performSearch needs the document requested first; the nodes it
+		// finds are pushed to the client, so the whole tree is not fetched
+		if _, err := dom.GetDocument().Do(ctx); err != nil {
+			return err
+		}
+		searchID, count, err := dom.PerformSearch(needle).Do(ctx)
+		if err != nil {
+			return err
+		}
+		defer dom.DiscardSearchResults(searchID).Do(ctx)
+		if count == 0 {
+			*html = ""
+			return nil
+		}
+		nodeIDs, err := dom.GetSearchResults(searchID, 0, min(count, maxDOMMatches)).Do(ctx)
+		if err != nil {
+			return err
+		}
+
+		var b strings.Builder
+		for _, id := range nodeIDs {
+			fragment, err := dom.GetOuterHTML().WithNodeID(id).Do(ctx)
+			if err != nil {
+				continue
+			}
+			if int64(b.Len()+len(fragment)) > maxSize {
+				break
+			}
+			b.WriteString(fragment)
+			b.WriteByte('\n')
+		}
+		*html = b.String()
+		return nil
+	})
+}
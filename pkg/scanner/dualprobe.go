@@ -0,0 +1,61 @@
+package scanner
+
+import (
+	"net/url"
+
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+)
+
+// dualProbe sends every special character twice, once raw and once
+// percent-encoded, and reports which characters come back unmodified for
+// each variant. WAFs and frameworks often decode only one of the two. With
+// --encoding-variants a third, double-encoded probe (%253C) catches
+// backends that decode a second time after the filter has run.
+func (s *Scanner) dualProbe(req *utils.Request, target utils.Target, reflectedInDOM bool) (allowedRaw, allowedEncoded, allowedDoubleEncoded []string) {
+	allowedRaw, allowedEncoded = []string{}, []string{}
+	variants := []string{"raw", "encoded"}
+	if s.opts.EncodingVariants {
+		allowedDoubleEncoded = []string{}
+		variants = append(variants, "double")
+	}
+	for _, char := range s.chars {
+		for _, variant := range variants {
+			s.resetState(req)
+			canary := s.newCanary(target.URL, target.Param)
+			payload, mode := canary+char, utils.EncodeAlways
+			switch variant {
+			case "raw":
+				mode = utils.EncodeNever
+			case "double":
+				// Encoding the already escaped character once more on the
+				// way out yields %253C for "<"
+				payload = canary + url.QueryEscape(char)
+			}
+			testTarget, ok := s.probeTargetEncoded(req, target.Param, payload, mode)
+			if !ok {
+				continue
+			}
+
+			var body string
+			var err error
+			if reflectedInDOM {
+				body, err = s.getDOM(testTarget)
+			} else {
+				body, err = s.fetch(testTarget)
+			}
+			if err != nil || !s.reflectsWith(body, canary, char) {
+				continue
+			}
+
+			switch variant {
+			case "raw":
+				allowedRaw = append(allowedRaw, char)
+			case "encoded":
+				allowedEncoded = append(allowedEncoded, char)
+			default:
+				allowedDoubleEncoded = append(allowedDoubleEncoded, char)
+			}
+		}
+	}
+	return allowedRaw, allowedEncoded, allowedDoubleEncoded
+}
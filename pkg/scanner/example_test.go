@@ -0,0 +1,45 @@
+package scanner_test
+
+import (
+	"fmt"
+
+	"github.com/bytes-Knight/xssrecon/pkg/scanner"
+)
+
+// collector is an OutputWriter that keeps every result in memory.
+type collector struct {
+	results []scanner.Result
+}
+
+func (c *collector) Write(result scanner.Result) error {
+	c.results = append(c.results, result)
+	return nil
+}
+
+func (c *collector) Close() error {
+	return nil
+}
+
+// Example scans one URL with the command's timeout and redirect defaults
+// and lists the parameters that reflected.
+func Example() {
+	results := &collector{}
+	s, err := scanner.NewScanner(scanner.Options{
+		Timeout:         15,
+		FollowRedirects: true,
+		MaxRedirects:    10,
+		Writer:          results,
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	s.Scan("https://example.com/search?q=test")
+	s.Close() // flushes and closes every writer, results included
+
+	for _, r := range results.results {
+		if r.Reflected {
+			fmt.Printf("%s reflects in %v\n", r.Param, r.Contexts)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package scanner
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// Execution is a suggested payload that ran when the page was loaded in
+// the browser.
+type Execution struct {
+	Context string `json:"context"`
+	Payload string `json:"payload"`
+	// Signal is how the execution was observed; "dialog" when the payload
+	// opened an alert and "console" when it logged a computed marker.
+	Signal string `json:"signal"`
+}
+
+// executionMarker returns a random number for a payload to pass to
+// alert(). Digits need no quotes, so every payload that can call alert(1)
+// can call it with the marker, and a dialog showing it can only come from
+// that payload.
+func executionMarker() string {
+	return strconv.Itoa(100000000 + rand.N(900000000))
+}
+
+// markPayload makes a suggested payload call alert with marker instead of 1.
+func markPayload(payload, marker string) string {
+	payload = strings.ReplaceAll(payload, "alert(1)", "alert("+marker+")")
+	return strings.ReplaceAll(payload, "alert`1`", "alert`"+marker+"`")
+}
+
+// consoleMarker returns an expression multiplying two random numbers and
+// its product. A page that logs its own URL shows the expression, never the
+// product, so only a payload that ran can log it.
+func consoleMarker() (expr, product string) {
+	a, b := 1000+rand.N(9000), 1000+rand.N(9000)
+	return strconv.Itoa(a) + "*" + strconv.Itoa(b), strconv.Itoa(a * b)
+}
+
+// consolePayload makes a suggested payload log expr instead of calling
+// alert(1).
+func consolePayload(payload, expr string) string {
+	payload = strings.ReplaceAll(payload, "alert(1)", "console.log("+expr+")")
+	return strings.ReplaceAll(payload, "alert`1`", "console.log`${"+expr+"}`")
+}
+
+// executionProbe loads each suggested payload, behind the canary, in the
+// browser and returns those that opened a dialog with their marker. A
+// payload that opens none is loaded again logging a computed marker to the
+// console instead, since pages sandboxed without allow-modals block dialogs
+// but still run scripts.
+func (s *Scanner) executionProbe(req *utils.Request, target utils.Target, suggestions []Suggestion) []Execution {
+	var executed []Execution
+	for _, sg := range suggestions {
+		marker := executionMarker()
+		ran := s.loadExecution(req, target, markPayload(sg.Payload, marker), func(ev any) bool {
+			d, ok := ev.(*page.EventJavascriptDialogOpening)
+			return ok && d.Message == marker
+		})
+		if ran {
+			executed = append(executed, Execution{Context: sg.Context, Payload: sg.Payload, Signal: "dialog"})
+			continue
+		}
+
+		expr, product := consoleMarker()
+		ran = s.loadExecution(req, target, consolePayload(sg.Payload, expr), func(ev any) bool {
+			c, ok := ev.(*runtime.EventConsoleAPICalled)
+			return ok && slices.ContainsFunc(c.Args, func(arg *runtime.RemoteObject) bool {
+				return string(arg.Value) == product
+			})
+		})
+		if ran {
+			executed = append(executed, Execution{Context: sg.Context, Payload: sg.Payload, Signal: "console"})
+		}
+	}
+	return executed
+}
+
+// loadExecution loads payload, behind the canary, in the browser and reports
+// whether any event of the tab satisfied seen.
+func (s *Scanner) loadExecution(req *utils.Request, target utils.Target, payload string, seen func(ev any) bool) bool {
+	s.resetState(req)
+	canary := s.newCanary(target.URL, target.Param)
+	testTarget, ok := s.probeTarget(req, target.Param, canary+payload)
+	if !ok || !isGet(testTarget) {
+		return false
+	}
+
+	var mu sync.Mutex
+	ran := false
+	s.limiter.Wait(testTarget.URL)
+	s.explainRequest("DOM", testTarget)
+	err := s.domScanner.load(testTarget, func(ev any) {
+		if seen(ev) {
+			mu.Lock()
+			ran = true
+			mu.Unlock()
+		}
+	})
+	if err != nil {
+		return false
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return ran
+}
+
+// printExecuted prints the payloads confirmed to execute, if any.
+func (s *Scanner) printExecuted(executed []Execution) {
+	if s.opts.JSONOutput {
+		return
+	}
+	for _, e := range executed {
+		line := fmt.Sprintf("CONFIRMED EXECUTION [%s] via %s: %s", e.Context, e.Signal, e.Payload)
+		if s.opts.NoColor {
+			fmt.Println(line)
+		} else {
+			fmt.Printf("\033[1;91m%s\033[0m\n", line)
+		}
+	}
+}
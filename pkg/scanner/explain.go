@@ -0,0 +1,119 @@
+package scanner
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+)
+
+// explainSnippetRadius is how many bytes are shown on each side of a
+// reflection, and explainMaxSnippets how many reflections are shown.
+const (
+	explainSnippetRadius = 40
+	explainMaxSnippets   = 3
+)
+
+// explainf prints one line of the explain command's reasoning.
+func (s *Scanner) explainf(format string, args ...any) {
+	if !s.opts.Explain || s.opts.JSONOutput {
+		return
+	}
+	if s.opts.NoColor {
+		fmt.Printf("EXPLAIN: "+format+"\n", args...)
+	} else {
+		fmt.Printf("\033[90mEXPLAIN: "+format+"\033[0m\n", args...)
+	}
+}
+
+// explainRequest shows a probe about to be sent, over HTTP or in the
+// browser, with the body, headers and cookies it injects into.
+func (s *Scanner) explainRequest(via string, target utils.Target) {
+	if !s.opts.Explain {
+		return
+	}
+	s.explainf("%s %s %s", via, cmp.Or(target.Method, http.MethodGet), target.URL)
+	if target.Body != "" {
+		s.explainf("  body: %s", target.Body)
+	}
+	for _, name := range slices.Sorted(maps.Keys(target.Headers)) {
+		s.explainf("  header %s: %s", name, target.Headers[name])
+	}
+	for _, name := range slices.Sorted(maps.Keys(target.Cookies)) {
+		s.explainf("  cookie %s=%s", name, target.Cookies[name])
+	}
+}
+
+// explainReflection lists where the canary appears in body, with the
+// surrounding markup and the HTML context of each occurrence.
+func (s *Scanner) explainReflection(source, body, canary string) {
+	if !s.opts.Explain {
+		return
+	}
+	spans := s.matcher.Match(body, canary)
+	if len(spans) == 0 {
+		s.explainf("%s response (%d bytes) does not contain the canary %s", source, len(body), canary)
+		return
+	}
+	s.explainf("%s response contains the canary %s %d time(s)", source, canary, len(spans))
+	for i, span := range spans {
+		if i == explainMaxSnippets {
+			s.explainf("  ... and %d more", len(spans)-i)
+			break
+		}
+		s.explainf("  #%d at offset %d in %s: %s", i+1, span[0], htmlContext(body, span[0]), snippet(body, span[0], span[1]-span[0]))
+	}
+}
+
+// explainChar gives the reason a probe was classified as it was. marker is
+// what was searched for: canary+char, or canary+conversion when converted.
+func (s *Scanner) explainChar(verdict, char, canary, marker, body string) {
+	if !s.opts.Explain {
+		return
+	}
+	label := printableChars([]string{char})[0]
+	switch verdict {
+	case "allowed":
+		off := strings.Index(body, marker)
+		s.explainf("%s allowed: %q came back unmodified: %s", label, marker, snippet(body, off, len(marker)))
+	case "converted":
+		off := strings.Index(body, marker)
+		s.explainf("%s converted: %q came back instead of %q: %s", label, marker, canary+char, snippet(body, off, len(marker)))
+	case "stripped":
+		var span [2]int
+		if spans := s.matcher.Match(body, canary); len(spans) > 0 {
+			span = spans[0]
+		}
+		next := body[span[1]:min(len(body), span[1]+10)]
+		s.explainf("%s stripped: the canary came back followed by %q instead (stripped or replaced): %s", label, next, snippet(body, span[0], span[1]-span[0]))
+	default:
+		s.explainf("%s blocked: the canary did not come back at all, so the request was rejected or the value dropped", label)
+	}
+}
+
+// occurrences returns the offsets of every occurrence of sub in s.
+func occurrences(s, sub string) []int {
+	var offsets []int
+	for start := 0; ; {
+		i := strings.Index(s[start:], sub)
+		if i < 0 {
+			return offsets
+		}
+		offsets = append(offsets, start+i)
+		start += i + len(sub)
+	}
+}
+
+// snippet quotes body around the n bytes at off.
+func snippet(body string, off, n int) string {
+	if off < 0 {
+		return `""`
+	}
+	start := max(0, off-explainSnippetRadius)
+	end := min(len(body), off+n+explainSnippetRadius)
+	return fmt.Sprintf("%q", body[start:end])
+}
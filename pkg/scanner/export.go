@@ -0,0 +1,230 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DocumentWriter collects the reflected findings of a scan and, on Close,
+// writes them to a file as a single JSON document built by render. It backs
+// the report formats of tools that import one file per scan.
+type DocumentWriter struct {
+	path   string
+	text   *reportText
+	render func([]JSONOutput, *reportText) any
+
+	mu       sync.Mutex
+	findings []JSONOutput
+}
+
+func newDocumentWriter(path, lang string, render func([]JSONOutput, *reportText) any) (*DocumentWriter, error) {
+	text, err := reportLanguage(lang)
+	if err != nil {
+		return nil, err
+	}
+	return &DocumentWriter{path: path, text: text, render: render}, nil
+}
+
+// NewFaradayWriter writes findings in Faraday's JSON import format, with
+// titles and descriptions in the given report language.
+func NewFaradayWriter(path, lang string) (*DocumentWriter, error) {
+	return newDocumentWriter(path, lang, renderFaraday)
+}
+
+// NewPlexTracWriter writes findings in PlexTrac's JSON import format, with
+// titles and descriptions in the given report language.
+func NewPlexTracWriter(path, lang string) (*DocumentWriter, error) {
+	return newDocumentWriter(path, lang, renderPlexTrac)
+}
+
+func (w *DocumentWriter) Write(output JSONOutput) error {
+	if !output.Reflected {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.findings = append(w.findings, output)
+	return nil
+}
+
+func (w *DocumentWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	f, err := os.Create(w.path)
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", w.path, err)
+	}
+	enc := json.NewEncoder(f)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(w.render(w.findings, w.text)); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Faraday import format: hosts, each with services carrying web vulnerabilities.
+type faradayReport struct {
+	Hosts   []*faradayHost `json:"hosts"`
+	Command faradayCommand `json:"command"`
+}
+
+type faradayCommand struct {
+	Tool    string `json:"tool"`
+	Command string `json:"command"`
+}
+
+type faradayHost struct {
+	IP              string            `json:"ip"`
+	Hostnames       []string          `json:"hostnames"`
+	Description     string            `json:"description"`
+	Services        []*faradayService `json:"services"`
+	Vulnerabilities []any             `json:"vulnerabilities"`
+}
+
+type faradayService struct {
+	Name            string        `json:"name"`
+	Port            int           `json:"port"`
+	Protocol        string        `json:"protocol"`
+	Status          string        `json:"status"`
+	Vulnerabilities []faradayVuln `json:"vulnerabilities"`
+}
+
+type faradayVuln struct {
+	Name       string   `json:"name"`
+	Desc       string   `json:"desc"`
+	Severity   string   `json:"severity"`
+	Type       string   `json:"type"`
+	Resolution string   `json:"resolution"`
+	Website    string   `json:"website"`
+	Path       string   `json:"path"`
+	Params     string   `json:"params"`
+	Query      string   `json:"query"`
+	Status     string   `json:"status"`
+	Refs       []string `json:"refs"`
+	CWE        []string `json:"cwe"`
+	ExternalID string   `json:"external_id"`
+	Confirmed  bool     `json:"confirmed"`
+	Data       string   `json:"data"`
+	Tags       []string `json:"tags"`
+}
+
+func renderFaraday(findings []JSONOutput, t *reportText) any {
+	report := faradayReport{
+		Hosts:   []*faradayHost{},
+		Command: faradayCommand{Tool: "xssrecon", Command: "xssrecon"},
+	}
+	hosts := make(map[string]*faradayHost)
+	services := make(map[string]*faradayService)
+	for _, f := range findings {
+		u, err := url.Parse(f.BaseURL)
+		if err != nil {
+			continue
+		}
+		hostname, port := u.Hostname(), urlPort(u)
+
+		host, ok := hosts[hostname]
+		if !ok {
+			host = &faradayHost{IP: hostname, Hostnames: []string{hostname}, Vulnerabilities: []any{}}
+			if ip := net.ParseIP(hostname); ip != nil {
+				host.Hostnames = []string{}
+			}
+			hosts[hostname] = host
+			report.Hosts = append(report.Hosts, host)
+		}
+		serviceKey := hostname + ":" + strconv.Itoa(port)
+		service, ok := services[serviceKey]
+		if !ok {
+			service = &faradayService{Name: u.Scheme, Port: port, Protocol: "tcp", Status: "open"}
+			services[serviceKey] = service
+			host.Services = append(host.Services, service)
+		}
+
+		service.Vulnerabilities = append(service.Vulnerabilities, faradayVuln{
+			Name:       findingTitle(f, t),
+			Desc:       findingDescription(f, t),
+			Severity:   strings.ToLower(findingSeverity(f)),
+			Type:       "VulnerabilityWeb",
+			Resolution: t.Remediation,
+			Website:    u.Scheme + "://" + u.Host,
+			Path:       u.Path,
+			Params:     f.Param,
+			Query:      u.RawQuery,
+			Status:     "open",
+			Refs:       []string{"https://owasp.org/www-community/attacks/xss/"},
+			CWE:        []string{"CWE-79"},
+			ExternalID: f.Fingerprint,
+			Data:       "Allowed: " + strings.Join(f.Allowed, " "),
+			Tags:       []string{"xss", "xssrecon"},
+		})
+	}
+	return report
+}
+
+// PlexTrac import format: a list of flaws with their affected assets.
+type plexTracReport struct {
+	Flaws []plexTracFlaw `json:"flaws"`
+}
+
+type plexTracFlaw struct {
+	Title           string          `json:"title"`
+	Severity        string          `json:"severity"`
+	Status          string          `json:"status"`
+	Description     string          `json:"description"`
+	Recommendations string          `json:"recommendations"`
+	References      string          `json:"references"`
+	AffectedAssets  []plexTracAsset `json:"affected_assets"`
+	Tags            []string        `json:"tags"`
+	Fields          map[string]any  `json:"fields"`
+}
+
+type plexTracAsset struct {
+	Asset     string   `json:"asset"`
+	Locations []string `json:"locations"`
+}
+
+func renderPlexTrac(findings []JSONOutput, t *reportText) any {
+	report := plexTracReport{Flaws: []plexTracFlaw{}}
+	for _, f := range findings {
+		asset := f.BaseURL
+		if u, err := url.Parse(f.BaseURL); err == nil {
+			asset = u.Host
+		}
+		report.Flaws = append(report.Flaws, plexTracFlaw{
+			Title:           findingTitle(f, t),
+			Severity:        findingSeverity(f),
+			Status:          "Open",
+			Description:     findingDescription(f, t),
+			Recommendations: t.Remediation,
+			References:      "https://owasp.org/www-community/attacks/xss/",
+			AffectedAssets:  []plexTracAsset{{Asset: asset, Locations: []string{f.BaseURL}}},
+			Tags:            []string{"xss", "xssrecon"},
+			Fields: map[string]any{
+				"parameter":   f.Param,
+				"fingerprint": f.Fingerprint,
+				"allowed":     f.Allowed,
+				"cwe":         "CWE-79",
+				"input_url":   f.Processing,
+			},
+		})
+	}
+	return report
+}
+
+// urlPort returns the explicit or scheme-default port of u.
+func urlPort(u *url.URL) int {
+	if p, err := strconv.Atoi(u.Port()); err == nil {
+		return p
+	}
+	if u.Scheme == "https" {
+		return 443
+	}
+	return 80
+}
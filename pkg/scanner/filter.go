@@ -0,0 +1,483 @@
+package scanner
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Filter is a compiled --filter expression deciding which results are
+// printed and written. Expressions combine result fields, named as in the
+// JSON output, with:
+//
+//	literals     "text", 'text', 12, 1.5, true, false, null
+//	fields       reflected, allowed, status_code, count.allowed
+//	operators    ! && || == != < <= > >= and parentheses
+//	functions    contains(list or text, value), len(x), matches(text, regex)
+//
+// A bare value is true unless it is false, null, zero or empty, and fields
+// a result does not carry are null. The regex of matches must be a string
+// literal; it is compiled with the filter. So
+//
+//	reflected && contains(allowed, "<") && !waf
+//
+// keeps reflected results that allow "<" and were not blocked by a WAF.
+type Filter struct {
+	eval func(env map[string]any) any
+}
+
+// ParseFilter compiles a --filter expression.
+func ParseFilter(expr string) (*Filter, error) {
+	toks, err := tokenizeFilter(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", expr, err)
+	}
+	p := &filterParser{toks: toks}
+	eval, err := p.parseOr()
+	if err == nil && p.pos < len(p.toks) {
+		err = fmt.Errorf("unexpected %q", p.toks[p.pos].text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", expr, err)
+	}
+	return &Filter{eval: eval}, nil
+}
+
+// Match reports whether output satisfies the filter. A nil filter matches
+// everything.
+func (f *Filter) Match(output JSONOutput) bool {
+	if f == nil {
+		return true
+	}
+	env, _ := filterValue(reflect.ValueOf(output)).(map[string]any)
+	return truthy(f.eval(env))
+}
+
+// filterValue converts v to the value encoding/json would decode it back
+// to: objects become maps keyed by JSON field name, without the omitempty
+// fields that are empty, lists become []any and numbers float64.
+func filterValue(v reflect.Value) any {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return filterValue(v.Elem())
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		list := make([]any, v.Len())
+		for i := range list {
+			list[i] = filterValue(v.Index(i))
+		}
+		return list
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		m := make(map[string]any, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			m[fmt.Sprint(iter.Key().Interface())] = filterValue(iter.Value())
+		}
+		return m
+	case reflect.Struct:
+		m := make(map[string]any)
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if !f.IsExported() || tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			if name == "" {
+				name = f.Name
+			}
+			if strings.Contains(","+opts+",", ",omitempty,") && isEmptyValue(v.Field(i)) {
+				continue
+			}
+			m[name] = filterValue(v.Field(i))
+		}
+		return m
+	}
+	return nil
+}
+
+// isEmptyValue reports whether omitempty leaves v out, as encoding/json
+// decides it.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	case reflect.Struct:
+		return false
+	}
+	return v.IsZero()
+}
+
+// printFiltered prints a result that passed --filter in text mode. Nothing
+// of a result is printed while it is being checked, so the ones the filter
+// drops leave no partial output behind.
+func (s *Scanner) printFiltered(output JSONOutput) {
+	text := &Scanner{opts: s.opts}
+	text.opts.JSONOutput = false
+	label := output.BaseURL
+	if output.Param != "" {
+		label += " [" + output.Param + "]"
+	}
+	text.printBaseURL(label)
+	if output.Skipped != "" {
+		text.printSkipped(output.Skipped)
+		return
+	}
+	if output.ErrorPage {
+		text.printErrorPage(output.StatusCode)
+	}
+	text.printReflected(output.Reflected)
+	if !output.Reflected {
+		return
+	}
+	text.printCSP(output.CSP)
+	text.printContexts(output.Contexts)
+	text.printOccurrences(output.Occurrences)
+	text.printCharResults(&output)
+	text.printWAF(output.WAF, output.WAFBlocked)
+	text.printSuggestions(output.Suggestions)
+	text.printExecuted(output.Executed)
+}
+
+type filterToken struct {
+	kind string // "op", "str", "num", "ident"
+	text string
+}
+
+func tokenizeFilter(expr string) ([]filterToken, error) {
+	var toks []filterToken
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '"' || c == '\'':
+			j := i + 1
+			var b strings.Builder
+			for ; j < len(expr) && expr[j] != c; j++ {
+				if expr[j] == '\\' && j+1 < len(expr) {
+					j++
+				}
+				b.WriteByte(expr[j])
+			}
+			if j == len(expr) {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			toks = append(toks, filterToken{"str", b.String()})
+			i = j + 1
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(expr) && (expr[j] >= '0' && expr[j] <= '9' || expr[j] == '.') {
+				j++
+			}
+			toks = append(toks, filterToken{"num", expr[i:j]})
+			i = j
+		case c == '_' || unicode.IsLetter(rune(c)):
+			j := i
+			for j < len(expr) && (expr[j] == '_' || expr[j] == '.' || unicode.IsLetter(rune(expr[j])) || unicode.IsDigit(rune(expr[j]))) {
+				j++
+			}
+			toks = append(toks, filterToken{"ident", expr[i:j]})
+			i = j
+		default:
+			op := ""
+			for _, o := range []string{"&&", "||", "==", "!=", "<=", ">=", "!", "<", ">", "(", ")", ","} {
+				if strings.HasPrefix(expr[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q", c)
+			}
+			toks = append(toks, filterToken{"op", op})
+			i += len(op)
+		}
+	}
+	return toks, nil
+}
+
+type filterParser struct {
+	toks []filterToken
+	pos  int
+}
+
+type filterNode = func(env map[string]any) any
+
+func (p *filterParser) accept(op string) bool {
+	if p.pos < len(p.toks) && p.toks[p.pos].kind == "op" && p.toks[p.pos].text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(env map[string]any) any { return truthy(l(env)) || truthy(right(env)) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(env map[string]any) any { return truthy(l(env)) && truthy(right(env)) }
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filterNode, error) {
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(env map[string]any) any { return !truthy(operand(env)) }, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (filterNode, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.accept(op) {
+			right, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			return func(env map[string]any) any { return compare(op, left(env), right(env)) }, nil
+		}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parsePrimary() (filterNode, error) {
+	if p.pos >= len(p.toks) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	if p.accept("(") {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing )")
+		}
+		return inner, nil
+	}
+
+	tok := p.toks[p.pos]
+	p.pos++
+	switch tok.kind {
+	case "str":
+		return func(map[string]any) any { return tok.text }, nil
+	case "num":
+		n, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", tok.text)
+		}
+		return func(map[string]any) any { return n }, nil
+	case "ident":
+		switch tok.text {
+		case "true", "false":
+			b := tok.text == "true"
+			return func(map[string]any) any { return b }, nil
+		case "null":
+			return func(map[string]any) any { return nil }, nil
+		}
+		if p.accept("(") {
+			return p.parseCall(tok.text)
+		}
+		path := strings.Split(tok.text, ".")
+		return func(env map[string]any) any { return lookupField(env, path) }, nil
+	}
+	return nil, fmt.Errorf("unexpected %q", tok.text)
+}
+
+func (p *filterParser) parseCall(name string) (filterNode, error) {
+	var args []filterNode
+	if !p.accept(")") {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.accept(")") {
+				break
+			}
+			if !p.accept(",") {
+				return nil, fmt.Errorf("expected , or ) in call to %s", name)
+			}
+		}
+	}
+
+	switch name {
+	case "contains":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("contains takes 2 arguments")
+		}
+		return func(env map[string]any) any { return containsValue(args[0](env), args[1](env)) }, nil
+	case "len":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("len takes 1 argument")
+		}
+		return func(env map[string]any) any { return float64(length(args[0](env))) }, nil
+	case "matches":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("matches takes 2 arguments")
+		}
+		// Only literals evaluate to a string without a result
+		pattern, ok := args[1](nil).(string)
+		if !ok {
+			return nil, fmt.Errorf("matches takes a string literal regex")
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex in matches: %w", err)
+		}
+		return func(env map[string]any) any { return re.MatchString(fmt.Sprint(args[0](env))) }, nil
+	}
+	return nil, fmt.Errorf("unknown function %s", name)
+}
+
+func lookupField(env map[string]any, path []string) any {
+	var v any = env
+	for _, key := range path {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	return v
+}
+
+func truthy(v any) bool {
+	switch x := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return x
+	case float64:
+		return x != 0
+	case string:
+		return x != ""
+	}
+	return length(v) > 0
+}
+
+func length(v any) int {
+	switch x := v.(type) {
+	case string:
+		return len(x)
+	case []any:
+		return len(x)
+	case map[string]any:
+		return len(x)
+	}
+	return 0
+}
+
+func containsValue(haystack, needle any) bool {
+	switch h := haystack.(type) {
+	case string:
+		return strings.Contains(h, fmt.Sprint(needle))
+	case []any:
+		for _, item := range h {
+			if reflect.DeepEqual(item, needle) {
+				return true
+			}
+		}
+	case map[string]any:
+		_, ok := h[fmt.Sprint(needle)]
+		return ok
+	}
+	return false
+}
+
+func compare(op string, a, b any) bool {
+	if x, ok := a.(float64); ok {
+		if y, ok := b.(float64); ok {
+			switch op {
+			case "==":
+				return x == y
+			case "!=":
+				return x != y
+			case "<":
+				return x < y
+			case "<=":
+				return x <= y
+			case ">":
+				return x > y
+			case ">=":
+				return x >= y
+			}
+		}
+	}
+	if x, ok := a.(string); ok {
+		if y, ok := b.(string); ok {
+			switch op {
+			case "<":
+				return x < y
+			case "<=":
+				return x <= y
+			case ">":
+				return x > y
+			case ">=":
+				return x >= y
+			}
+		}
+	}
+	switch op {
+	case "==":
+		return reflect.DeepEqual(a, b)
+	case "!=":
+		return !reflect.DeepEqual(a, b)
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Finding severities, named as most trackers name them.
+const (
+	SeverityHigh   = "High"
+	SeverityMedium = "Medium"
+	SeverityLow    = "Low"
+)
+
+// findingSeverity rates a reflected result by the characters that survive:
+// angle brackets allow new tags, quotes and backticks allow breaking out of
+// attributes and strings, anything else is a plain reflection.
+func findingSeverity(output JSONOutput) string {
+	allowed := output.Allowed
+	switch {
+	case len(output.Executed) > 0:
+		return SeverityHigh
+	case slices.Contains(allowed, "<") && slices.Contains(allowed, ">"):
+		return SeverityHigh
+	case slices.Contains(allowed, `"`) || slices.Contains(allowed, "'") || slices.Contains(allowed, "`"):
+		return SeverityMedium
+	}
+	return SeverityLow
+}
+
+// findingTitle describes a result in one line.
+func findingTitle(output JSONOutput, t *reportText) string {
+	host, path := output.BaseURL, ""
+	if u, err := url.Parse(output.BaseURL); err == nil {
+		host, path = u.Host, u.Path
+	}
+	param := output.Param
+	if param == "" {
+		param = t.Input
+	}
+	return fmt.Sprintf(t.Title, param, host, path)
+}
+
+// findingDescription lists the probe results of a finding as Markdown.
+func findingDescription(output JSONOutput, t *reportText) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, t.Intro, output.Canary, output.Param, output.BaseURL)
+	fmt.Fprintf(&b, t.Allowed, strings.Join(output.Allowed, " "))
+	fmt.Fprintf(&b, t.Blocked, strings.Join(output.Blocked, " "))
+	if len(output.Stripped) > 0 {
+		fmt.Fprintf(&b, t.Stripped, strings.Join(output.Stripped, " "))
+	}
+	fmt.Fprintf(&b, t.Converted, strings.Join(output.Converted, ", "))
+	for _, e := range output.Executed {
+		fmt.Fprintf(&b, t.Executed, e.Signal, e.Payload)
+	}
+	if output.MimeSniffing != nil {
+		fmt.Fprintf(&b, t.MimeSniff, output.MimeSniffing.ContentType)
+	}
+	return b.String()
+}
+
+// Fingerprint returns a stable identifier for a finding: a hash of the host
+// and path of the scanned input URL, the parameter, and the context
+// ("http" or "dom") the canary reflected in. Query values and canaries are
+// left out so the same injection point keeps its fingerprint across scans.
+func Fingerprint(inputURL, param, context string) string {
+	key := inputURL
+	if u, err := url.Parse(inputURL); err == nil {
+		key = strings.ToLower(u.Host) + u.Path
+	}
+	sum := sha256.Sum256([]byte(key + "\x00" + param + "\x00" + context))
+	return hex.EncodeToString(sum[:8])
+}
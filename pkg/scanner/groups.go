@@ -0,0 +1,158 @@
+package scanner
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TargetGroup is one entry of a --groups file: a set of targets scanned
+// with its own pacing, headers and credentials. Zero values inherit the
+// command-line setting.
+type TargetGroup struct {
+	Name string `json:"name"`
+	// Targets is a file of URLs, one per line, resolved relative to the
+	// groups file; URLs lists targets inline. Both may be given.
+	Targets string   `json:"targets,omitempty"`
+	URLs    []string `json:"urls,omitempty"`
+
+	Concurrency      int     `json:"concurrency,omitempty"`
+	RateLimit        float64 `json:"rate_limit,omitempty"`
+	RateLimitPerHost float64 `json:"rate_limit_per_host,omitempty"`
+	Delay            string  `json:"delay,omitempty"`
+	Jitter           string  `json:"jitter,omitempty"`
+
+	// Headers are sent with every request of the group.
+	Headers    map[string]string `json:"headers,omitempty"`
+	Cookie     string            `json:"cookie,omitempty"`
+	CookieFile string            `json:"cookie_file,omitempty"`
+	AuthBasic  string            `json:"auth_basic,omitempty"`
+	AuthBearer string            `json:"auth_bearer,omitempty"`
+
+	delay, jitter time.Duration
+}
+
+var groupName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// LoadGroups reads a --groups file, a JSON object with a "groups" array of
+// TargetGroup, and loads each group's target list.
+func LoadGroups(path string) ([]TargetGroup, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading groups file: %w", err)
+	}
+	var file struct {
+		Groups []TargetGroup `json:"groups"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing groups file: %w", err)
+	}
+	if len(file.Groups) == 0 {
+		return nil, fmt.Errorf("groups file %s has no groups", path)
+	}
+
+	seen := make(map[string]bool)
+	for i := range file.Groups {
+		g := &file.Groups[i]
+		if !groupName.MatchString(g.Name) {
+			return nil, fmt.Errorf("group %d: name %q must be non-empty letters, digits, - or _", i+1, g.Name)
+		}
+		if seen[g.Name] {
+			return nil, fmt.Errorf("group %s is defined twice", g.Name)
+		}
+		seen[g.Name] = true
+
+		for _, d := range []struct {
+			field string
+			value string
+			dst   *time.Duration
+		}{{"delay", g.Delay, &g.delay}, {"jitter", g.Jitter, &g.jitter}} {
+			if d.value == "" {
+				continue
+			}
+			if *d.dst, err = time.ParseDuration(d.value); err != nil {
+				return nil, fmt.Errorf("group %s: invalid %s: %w", g.Name, d.field, err)
+			}
+		}
+
+		if g.Targets != "" {
+			targets := g.Targets
+			if !filepath.IsAbs(targets) {
+				targets = filepath.Join(filepath.Dir(path), targets)
+			}
+			urls, err := readLines(targets)
+			if err != nil {
+				return nil, fmt.Errorf("group %s: %w", g.Name, err)
+			}
+			g.URLs = append(g.URLs, urls...)
+		}
+		if len(g.URLs) == 0 {
+			return nil, fmt.Errorf("group %s has no targets", g.Name)
+		}
+	}
+	return file.Groups, nil
+}
+
+// readLines returns the non-blank lines of the file at path.
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if line := strings.TrimSpace(sc.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, sc.Err()
+}
+
+// ForGroup returns the options for scanning g: the group's settings
+// override opts, and every file the scanner writes gets the group name
+// inserted before its extensions (results.jsonl becomes
+// results.prod.jsonl) so that groups never share state or output.
+func (opts Options) ForGroup(g TargetGroup) Options {
+	if g.Concurrency > 0 {
+		opts.Concurrency = g.Concurrency
+	}
+	if g.RateLimit > 0 {
+		opts.RateLimit = g.RateLimit
+	}
+	if g.RateLimitPerHost > 0 {
+		opts.RateLimitPerHost = g.RateLimitPerHost
+	}
+	if g.delay > 0 {
+		opts.Delay = g.delay
+	}
+	if g.jitter > 0 {
+		opts.Jitter = g.jitter
+	}
+	if g.Cookie != "" || g.CookieFile != "" {
+		opts.Cookie, opts.CookieFile = g.Cookie, g.CookieFile
+	}
+	if g.AuthBasic != "" || g.AuthBearer != "" {
+		opts.AuthBasic, opts.AuthBearer = g.AuthBasic, g.AuthBearer
+	}
+
+	for _, path := range []*string{
+		&opts.Output, &opts.Manifest, &opts.Record, &opts.HostCache,
+		&opts.FaradayOutput, &opts.PlexTracOutput, &opts.TreeOutput,
+	} {
+		if *path != "" {
+			*path = partitionName(*path, g.Name)
+		}
+	}
+	if opts.ArtifactsDir != "" {
+		opts.ArtifactsDir = filepath.Join(opts.ArtifactsDir, g.Name)
+	}
+	return opts
+}
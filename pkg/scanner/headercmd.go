@@ -0,0 +1,123 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// headerCmdTimeout bounds a single run of the --header-cmd command.
+const headerCmdTimeout = 30 * time.Second
+
+// headerCmdMinRefresh is the shortest time between two runs forced by
+// 401 or 407 responses, so a rejected token can't trigger a command storm.
+const headerCmdMinRefresh = 10 * time.Second
+
+// headerCommand resolves request headers from the output of an external
+// command, such as one fetching a short-lived token from a secrets store.
+// Its output is cached for ttl and refreshed when it expires or when the
+// target rejects the credentials. A nil *headerCommand adds no headers.
+type headerCommand struct {
+	command string
+	ttl     time.Duration
+
+	mu      sync.Mutex
+	headers map[string]string
+	fetched time.Time
+	stale   bool
+}
+
+func newHeaderCommand(command string, ttl time.Duration) (*headerCommand, error) {
+	if command == "" {
+		return nil, nil
+	}
+	c := &headerCommand{command: command, ttl: ttl}
+	// Run once up front so a broken command fails the scan immediately
+	if _, err := c.Headers(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Headers returns the current headers, running the command when the cached
+// output has expired. If a refresh fails the previous headers are kept.
+func (c *headerCommand) Headers() (map[string]string, error) {
+	if c == nil {
+		return nil, nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.headers != nil && !c.stale && (c.ttl <= 0 || time.Since(c.fetched) < c.ttl) {
+		return c.headers, nil
+	}
+
+	headers, err := c.run()
+	if err != nil {
+		if c.headers != nil {
+			return c.headers, err
+		}
+		return nil, err
+	}
+	c.headers, c.fetched, c.stale = headers, time.Now(), false
+	return headers, nil
+}
+
+// Rejected marks the headers stale after a 401 or 407 response, at most
+// once every headerCmdMinRefresh.
+func (c *headerCommand) Rejected(status int) {
+	if c == nil || (status != http.StatusUnauthorized && status != http.StatusProxyAuthRequired) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if time.Since(c.fetched) >= headerCmdMinRefresh {
+		c.stale = true
+	}
+}
+
+func (c *headerCommand) run() (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), headerCmdTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.command)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("running --header-cmd: %w", err)
+	}
+	return parseHeaderOutput(string(out))
+}
+
+// parseHeaderOutput reads "Name: value" lines. Output that is a single line
+// without a colon is taken as a bearer token.
+func parseHeaderOutput(out string) (map[string]string, error) {
+	out = strings.TrimSpace(out)
+	if out == "" {
+		return nil, errors.New("--header-cmd printed nothing")
+	}
+	if !strings.Contains(out, "\n") && !strings.Contains(out, ":") {
+		return map[string]string{"Authorization": "Bearer " + out}, nil
+	}
+
+	headers := make(map[string]string)
+	sc := bufio.NewScanner(strings.NewReader(out))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("--header-cmd printed %q, want \"Name: value\" lines", line)
+		}
+		headers[http.CanonicalHeaderKey(name)] = strings.TrimSpace(value)
+	}
+	return headers, nil
+}
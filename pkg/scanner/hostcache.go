@@ -0,0 +1,255 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HostKnowledge is what earlier runs learned about a host.
+type HostKnowledge struct {
+	// WAF is the last WAF seen blocking a request to the host.
+	WAF string `json:"waf,omitempty"`
+	// Requests and AvgLatencyMs describe how fast the host answers.
+	Requests     int     `json:"requests"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	// DOMChecks counts browser fallbacks and DOMReflections how many of them
+	// found a reflection the HTTP response did not have, since
+	// DOMChecksSince; the counts restart once they are older than the TTL.
+	DOMChecks       int       `json:"dom_checks"`
+	DOMReflections  int       `json:"dom_reflections"`
+	HTTPReflections int       `json:"http_reflections"`
+	DOMChecksSince  time.Time `json:"dom_checks_since"`
+	// Encoders lists the output encodings observed, such as "html-entity".
+	Encoders []string `json:"encoders,omitempty"`
+	// Params tracks, by "path param", how parameters of the host's pages
+	// fared in consecutive scans.
+	Params    map[string]*ParamHistory `json:"params,omitempty"`
+	UpdatedAt time.Time                `json:"updated_at"`
+}
+
+// ParamHistory counts the consecutive scans in which a parameter did not
+// reflect while its page kept the same PageHash.
+type ParamHistory struct {
+	Negatives int    `json:"negatives"`
+	PageHash  string `json:"page_hash"`
+}
+
+// hostCacheMinDOMChecks is how many browser fallbacks must have come back
+// empty before a host is considered not to need them.
+const hostCacheMinDOMChecks = 5
+
+// HostCache persists HostKnowledge across runs in a JSON file so repeat scans
+// can skip the browser on hosts that never needed it and start it early on
+// hosts that do. A nil *HostCache records nothing.
+type HostCache struct {
+	path string
+	// domTTL is how long DOM check counts are trusted (0 is forever), and
+	// forceDOM stops SkipDOM from ever skipping; see --host-cache-ttl and
+	// --force-dom.
+	domTTL   time.Duration
+	forceDOM bool
+
+	mu    sync.Mutex
+	Hosts map[string]*HostKnowledge `json:"hosts"`
+}
+
+// LoadHostCache reads the cache at path; a missing file starts an empty
+// cache. DOM check counts older than domTTL are not acted on, and with
+// forceDOM no host has its DOM checks skipped.
+func LoadHostCache(path string, domTTL time.Duration, forceDOM bool) (*HostCache, error) {
+	c := &HostCache{path: path, domTTL: domTTL, forceDOM: forceDOM, Hosts: make(map[string]*HostKnowledge)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading host cache: %w", err)
+	}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("parsing host cache: %w", err)
+	}
+	if c.Hosts == nil {
+		c.Hosts = make(map[string]*HostKnowledge)
+	}
+	return c, nil
+}
+
+// Save writes the cache back to its file.
+func (c *HostCache) Save() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	data, err := json.MarshalIndent(c, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(c.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(c.path, data, 0o644)
+}
+
+// update runs fn on the knowledge for the host of rawURL.
+func (c *HostCache) update(rawURL string, fn func(*HostKnowledge)) {
+	if c == nil {
+		return
+	}
+	host := cacheHost(rawURL)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k, ok := c.Hosts[host]
+	if !ok {
+		k = &HostKnowledge{}
+		c.Hosts[host] = k
+	}
+	fn(k)
+	k.UpdatedAt = time.Now().UTC()
+}
+
+func (c *HostCache) get(rawURL string) (HostKnowledge, bool) {
+	if c == nil {
+		return HostKnowledge{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k, ok := c.Hosts[cacheHost(rawURL)]
+	if !ok {
+		return HostKnowledge{}, false
+	}
+	return *k, true
+}
+
+func cacheHost(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return strings.ToLower(u.Host)
+	}
+	return rawURL
+}
+
+// RecordResponse folds one HTTP response into the host's latency average
+// and the WAF that blocked it, if any.
+func (c *HostCache) RecordResponse(rawURL string, latency time.Duration, waf string) {
+	c.update(rawURL, func(k *HostKnowledge) {
+		ms := float64(latency) / float64(time.Millisecond)
+		k.AvgLatencyMs = (k.AvgLatencyMs*float64(k.Requests) + ms) / float64(k.Requests+1)
+		k.Requests++
+		if waf != "" {
+			k.WAF = waf
+		}
+	})
+}
+
+// expired reports whether counts gathered since t are too old to act on.
+func (c *HostCache) expired(t time.Time) bool {
+	return c.domTTL > 0 && time.Since(t) > c.domTTL
+}
+
+// RecordReflection records the outcome of a base URL check. Expired counts
+// are dropped first, so the host's DOM needs are learned again.
+func (c *HostCache) RecordReflection(rawURL string, domChecked, reflected, inDOM bool) {
+	c.update(rawURL, func(k *HostKnowledge) {
+		if k.DOMChecksSince.IsZero() || c.expired(k.DOMChecksSince) {
+			k.DOMChecks, k.DOMReflections, k.HTTPReflections = 0, 0, 0
+			k.DOMChecksSince = time.Now().UTC()
+		}
+		if domChecked {
+			k.DOMChecks++
+		}
+		switch {
+		case reflected && inDOM:
+			k.DOMReflections++
+		case reflected:
+			k.HTTPReflections++
+		}
+	})
+}
+
+// RecordEncoder notes an output encoding observed on the host.
+func (c *HostCache) RecordEncoder(rawURL, encoder string) {
+	c.update(rawURL, func(k *HostKnowledge) {
+		if !slices.Contains(k.Encoders, encoder) {
+			k.Encoders = append(k.Encoders, encoder)
+		}
+	})
+}
+
+// SkipDOM reports whether recent runs showed the browser fallback to be
+// useless on the host of rawURL, with the reason.
+func (c *HostCache) SkipDOM(rawURL string) (string, bool) {
+	k, ok := c.get(rawURL)
+	if !ok || c.forceDOM || k.DOMChecks < hostCacheMinDOMChecks || k.DOMReflections > 0 || c.expired(k.DOMChecksSince) {
+		return "", false
+	}
+	return fmt.Sprintf("host cache: no DOM-only reflections in %d checks", k.DOMChecks), true
+}
+
+// NeedsDOM reports whether the host of rawURL mostly reflects only in the
+// rendered DOM, so the browser is worth starting right away.
+func (c *HostCache) NeedsDOM(rawURL string) bool {
+	k, ok := c.get(rawURL)
+	return ok && k.DOMReflections > 0 && k.DOMReflections >= k.HTTPReflections
+}
+
+// pageHash fingerprints a non-reflecting response so later runs can tell
+// whether the page changed. Pages with per-request content such as CSRF
+// tokens or timestamps never hash the same twice.
+func pageHash(resp *response) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d\n%s", resp.StatusCode, resp.Body)))
+	return hex.EncodeToString(sum[:8])
+}
+
+func paramKey(rawURL, param string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		return u.Path + " " + param
+	}
+	return rawURL + " " + param
+}
+
+// RecordParam records whether param of the page at rawURL reflected, with
+// the hash of the page it was checked on.
+func (c *HostCache) RecordParam(rawURL, param, hash string, reflected bool) {
+	c.update(rawURL, func(k *HostKnowledge) {
+		if k.Params == nil {
+			k.Params = make(map[string]*ParamHistory)
+		}
+		key := paramKey(rawURL, param)
+		h, ok := k.Params[key]
+		if !ok || reflected || h.PageHash != hash {
+			h = &ParamHistory{PageHash: hash}
+			k.Params[key] = h
+		}
+		if !reflected {
+			h.Negatives++
+		}
+	})
+}
+
+// KnownNegative reports whether param of the page at rawURL did not reflect
+// in at least n consecutive earlier scans of a page hashing to hash.
+func (c *HostCache) KnownNegative(rawURL, param, hash string, n int) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k, ok := c.Hosts[cacheHost(rawURL)]
+	if !ok {
+		return false
+	}
+	h, ok := k.Params[paramKey(rawURL, param)]
+	return ok && h.PageHash == hash && h.Negatives >= n
+}
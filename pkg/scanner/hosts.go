@@ -0,0 +1,88 @@
+package scanner
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// contactedHosts counts the requests sent to every host over a run,
+// including redirect targets, and the redirects refused as off-scope. It is
+// safe for concurrent use.
+type contactedHosts struct {
+	mu       sync.Mutex
+	requests map[string]int
+	refused  map[string]int
+}
+
+func newContactedHosts() *contactedHosts {
+	return &contactedHosts{
+		requests: make(map[string]int),
+		refused:  make(map[string]int),
+	}
+}
+
+// Add records a request to u's host.
+func (c *contactedHosts) Add(u *url.URL) {
+	c.mu.Lock()
+	c.requests[strings.ToLower(u.Host)]++
+	c.mu.Unlock()
+}
+
+// Refuse records a redirect to u's host that was not followed.
+func (c *contactedHosts) Refuse(u *url.URL) {
+	c.mu.Lock()
+	c.refused[strings.ToLower(u.Host)]++
+	c.mu.Unlock()
+}
+
+// Snapshot returns copies of the request and refusal counts by host.
+func (c *contactedHosts) Snapshot() (requests, refused map[string]int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	requests = make(map[string]int, len(c.requests))
+	for h, n := range c.requests {
+		requests[h] = n
+	}
+	refused = make(map[string]int, len(c.refused))
+	for h, n := range c.refused {
+		refused[h] = n
+	}
+	return requests, refused
+}
+
+// offScope reports whether a redirect to next leaves the host of the
+// request that started the chain.
+func offScope(next, first *url.URL) bool {
+	return !strings.EqualFold(next.Hostname(), first.Hostname())
+}
+
+// printContactedHosts writes the hosts contacted during the run to stderr,
+// keeping stdout free for results.
+func (s *Scanner) printContactedHosts() {
+	requests, refused := s.hosts.Snapshot()
+	if len(requests) == 0 && len(refused) == 0 {
+		return
+	}
+	hosts := make([]string, 0, len(requests))
+	for h := range requests {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+	fmt.Fprintf(os.Stderr, "CONTACTED HOSTS: %d\n", len(hosts))
+	for _, h := range hosts {
+		fmt.Fprintf(os.Stderr, "  %s (%d requests)\n", h, requests[h])
+	}
+
+	hosts = hosts[:0]
+	for h := range refused {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+	for _, h := range hosts {
+		fmt.Fprintf(os.Stderr, "REFUSED OFF-SCOPE REDIRECT: %s (%d times)\n", h, refused[h])
+	}
+}
@@ -0,0 +1,103 @@
+package scanner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// reportText holds the translatable strings of exported findings and
+// notifications. Each format string is a whole line, list marker and
+// newline included, so translations control the full text; the comments
+// give the arguments.
+type reportText struct {
+	Title       string // parameter, host, path
+	Input       string // used when a finding has no parameter name
+	Intro       string // canary, parameter, URL
+	Allowed     string // characters
+	Blocked     string // characters
+	Stripped    string // characters
+	Converted   string // conversions
+	MimeSniff   string // content type
+	Executed    string // signal, payload
+	Remediation string
+	Digest      string // findings, hosts, interval
+	DigestMore  string // findings not listed
+}
+
+// reportLanguages maps --lang codes to their report strings.
+var reportLanguages = map[string]*reportText{
+	"en": {
+		Title:       "Reflected XSS candidate in %s at %s%s",
+		Input:       "input",
+		Intro:       "The canary `%s` sent in `%s` is reflected in the response of %s.\n\n",
+		Allowed:     "- Allowed: %s\n",
+		Blocked:     "- Blocked: %s\n",
+		Stripped:    "- Stripped: %s\n",
+		Converted:   "- Converted: %s\n",
+		MimeSniff:   "- Served as %q without nosniff (MIME sniffing required)\n",
+		Executed:    "- Confirmed execution (%s): `%s`\n",
+		Remediation: "Encode user input for the context it is written to (HTML body, attribute, JavaScript string or URL) and reject or strip characters that are not expected in the parameter. A restrictive Content-Security-Policy limits the impact of any remaining injection.",
+		Digest:      "xssrecon: %d findings on %d hosts in the last %s",
+		DigestMore:  "… and %d more",
+	},
+	"es": {
+		Title:       "Posible XSS reflejado en %s en %s%s",
+		Input:       "entrada",
+		Intro:       "El canario `%s` enviado en `%s` se refleja en la respuesta de %s.\n\n",
+		Allowed:     "- Permitidos: %s\n",
+		Blocked:     "- Bloqueados: %s\n",
+		Stripped:    "- Eliminados: %s\n",
+		Converted:   "- Convertidos: %s\n",
+		MimeSniff:   "- Servido como %q sin nosniff (requiere MIME sniffing)\n",
+		Executed:    "- Ejecución confirmada (%s): `%s`\n",
+		Remediation: "Codifique la entrada del usuario según el contexto en el que se escribe (cuerpo HTML, atributo, cadena JavaScript o URL) y rechace o elimine los caracteres que no se esperan en el parámetro. Una Content-Security-Policy restrictiva limita el impacto de cualquier inyección restante.",
+		Digest:      "xssrecon: %d hallazgos en %d hosts en los últimos %s",
+		DigestMore:  "… y %d más",
+	},
+	"fr": {
+		Title:       "XSS réfléchi potentiel dans %s sur %s%s",
+		Input:       "entrée",
+		Intro:       "Le canari `%s` envoyé dans `%s` est reflété dans la réponse de %s.\n\n",
+		Allowed:     "- Autorisés : %s\n",
+		Blocked:     "- Bloqués : %s\n",
+		Stripped:    "- Supprimés : %s\n",
+		Converted:   "- Convertis : %s\n",
+		MimeSniff:   "- Servi en tant que %q sans nosniff (MIME sniffing requis)\n",
+		Executed:    "- Exécution confirmée (%s) : `%s`\n",
+		Remediation: "Encodez les entrées utilisateur selon le contexte dans lequel elles sont écrites (corps HTML, attribut, chaîne JavaScript ou URL) et rejetez ou supprimez les caractères inattendus dans le paramètre. Une Content-Security-Policy restrictive limite l'impact de toute injection restante.",
+		Digest:      "xssrecon : %d résultats sur %d hôtes en %s",
+		DigestMore:  "… et %d de plus",
+	},
+	"de": {
+		Title:       "Mögliches reflektiertes XSS in %s auf %s%s",
+		Input:       "Eingabe",
+		Intro:       "Der Canary `%s`, gesendet in `%s`, wird in der Antwort von %s reflektiert.\n\n",
+		Allowed:     "- Erlaubt: %s\n",
+		Blocked:     "- Blockiert: %s\n",
+		Stripped:    "- Entfernt: %s\n",
+		Converted:   "- Umgewandelt: %s\n",
+		MimeSniff:   "- Ausgeliefert als %q ohne nosniff (MIME-Sniffing erforderlich)\n",
+		Executed:    "- Ausführung bestätigt (%s): `%s`\n",
+		Remediation: "Benutzereingaben passend zum Kontext kodieren, in den sie geschrieben werden (HTML-Body, Attribut, JavaScript-String oder URL), und im Parameter nicht erwartete Zeichen ablehnen oder entfernen. Eine restriktive Content-Security-Policy begrenzt die Auswirkungen verbleibender Injektionen.",
+		Digest:      "xssrecon: %d Funde auf %d Hosts in den letzten %s",
+		DigestMore:  "… und %d weitere",
+	},
+}
+
+// reportLanguage returns the report strings for lang, defaulting to English.
+func reportLanguage(lang string) (*reportText, error) {
+	if lang == "" {
+		lang = "en"
+	}
+	t, ok := reportLanguages[strings.ToLower(lang)]
+	if !ok {
+		codes := make([]string, 0, len(reportLanguages))
+		for code := range reportLanguages {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		return nil, fmt.Errorf("unsupported report language %q (use %s)", lang, strings.Join(codes, ", "))
+	}
+	return t, nil
+}
@@ -0,0 +1,148 @@
+package scanner
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+)
+
+// JUnitWriter collects every result of a scan and, on Close, writes them as
+// a JUnit XML report for CI test report views: one test case per injection
+// point, grouped into a test suite per host, failing when the reflection is
+// exploitable (a Medium or High finding) and skipped when the target was
+// reported without probing.
+type JUnitWriter struct {
+	path string
+	text *reportText
+
+	mu      sync.Mutex
+	results []JSONOutput
+}
+
+// NewJUnitWriter writes results to path as JUnit XML, with failure messages
+// in the given report language.
+func NewJUnitWriter(path, lang string) (*JUnitWriter, error) {
+	text, err := reportLanguage(lang)
+	if err != nil {
+		return nil, err
+	}
+	return &JUnitWriter{path: path, text: text}, nil
+}
+
+func (w *JUnitWriter) Write(output JSONOutput) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.results = append(w.results, output)
+	return nil
+}
+
+func (w *JUnitWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	f, err := os.Create(w.path)
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", w.path, err)
+	}
+	if _, err := f.WriteString(xml.Header); err != nil {
+		f.Close()
+		return err
+	}
+	enc := xml.NewEncoder(f)
+	enc.Indent("", "  ")
+	if err := enc.Encode(renderJUnit(w.results, w.text)); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.WriteString("\n"); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+func renderJUnit(results []JSONOutput, t *reportText) junitSuites {
+	report := junitSuites{Name: "xssrecon"}
+	suites := make(map[string]*junitSuite)
+	for _, r := range results {
+		host, path := r.BaseURL, ""
+		if u, err := url.Parse(r.BaseURL); err == nil && u.Host != "" {
+			host, path = u.Host, u.Path
+		}
+		suite := suites[host]
+		if suite == nil {
+			suite = &junitSuite{Name: host}
+			suites[host] = suite
+		}
+
+		name := r.Param
+		if name == "" {
+			name = t.Input
+		}
+		tc := junitCase{Name: name, Classname: host + path}
+		switch {
+		case r.Skipped != "":
+			tc.Skipped = &junitSkipped{Message: r.Skipped}
+			suite.Skipped++
+		case r.Reflected && findingSeverity(r) != SeverityLow:
+			tc.Failure = &junitFailure{
+				Message: findingTitle(r, t),
+				Type:    findingSeverity(r),
+				Text:    findingDescription(r, t),
+			}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+		suite.Tests++
+	}
+
+	hosts := make([]string, 0, len(suites))
+	for host := range suites {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	for _, host := range hosts {
+		suite := suites[host]
+		report.Suites = append(report.Suites, *suite)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+	}
+	return report
+}
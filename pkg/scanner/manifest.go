@@ -0,0 +1,126 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bytes-Knight/xssrecon/banner"
+)
+
+// Manifest records everything needed to reproduce a scan: the effective
+// options, canary scheme, tool version, hashes of the probe sets and when
+// the scan ran.
+type Manifest struct {
+	Tool          string            `json:"tool"`
+	Version       string            `json:"version"`
+	Options       Options           `json:"options"`
+	CanaryScheme  string            `json:"canary_scheme"`
+	PayloadHashes map[string]string `json:"payload_hashes"`
+	StartedAt     time.Time         `json:"started_at"`
+	FinishedAt    *time.Time        `json:"finished_at,omitempty"`
+	// ContactedHosts counts the requests sent to each host, redirect
+	// targets included; RefusedRedirects counts off-scope redirects that
+	// were not followed.
+	ContactedHosts   map[string]int `json:"contacted_hosts,omitempty"`
+	RefusedRedirects map[string]int `json:"refused_redirects,omitempty"`
+}
+
+// manifestPath returns where the manifest should be written, if anywhere.
+func (s *Scanner) manifestPath() string {
+	if s.opts.Manifest != "" {
+		return s.opts.Manifest
+	}
+	if s.opts.Output != "" {
+		return s.opts.Output + ".manifest.json"
+	}
+	return ""
+}
+
+func (s *Scanner) buildManifest(finished bool) Manifest {
+	opts := s.opts
+	if opts.Cookie != "" {
+		opts.Cookie = "REDACTED"
+	}
+	if u, err := url.Parse(opts.Proxy); err == nil && u.User != nil {
+		u.User = url.User("REDACTED")
+		opts.Proxy = u.String()
+	}
+	if opts.DefectDojoKey != "" {
+		opts.DefectDojoKey = "REDACTED"
+	}
+	if opts.AuthBasic != "" {
+		opts.AuthBasic = "REDACTED"
+	}
+	if opts.AuthBearer != "" {
+		opts.AuthBearer = "REDACTED"
+	}
+	if opts.NotifyWebhook != "" {
+		opts.NotifyWebhook = "REDACTED"
+	}
+	if opts.HeaderCmd != "" {
+		opts.HeaderCmd = "REDACTED"
+	}
+
+	scheme := "fixed:" + s.canary
+	if opts.UniqueCanaries {
+		scheme = "unique:xk<paramhash><counter>k"
+	}
+	if opts.PageCanary {
+		scheme = "page-prefix+" + scheme
+	}
+
+	var convKeys []string
+	for _, char := range s.chars {
+		for _, f := range conversionForms(char) {
+			convKeys = append(convKeys, char+"="+f.text)
+		}
+	}
+	sort.Strings(convKeys)
+
+	m := Manifest{
+		Tool:         "xssrecon",
+		Version:      banner.Version,
+		Options:      opts,
+		CanaryScheme: scheme,
+		PayloadHashes: map[string]string{
+			"special_chars": hashStrings(s.chars),
+			"conversions":   hashStrings(convKeys),
+		},
+		StartedAt: s.startedAt,
+	}
+	if finished {
+		now := time.Now().UTC()
+		m.FinishedAt = &now
+		m.ContactedHosts, m.RefusedRedirects = s.hosts.Snapshot()
+	}
+	return m
+}
+
+// writeManifest writes the manifest file. It is called once when the
+// scanner starts and again with the finish time when it is closed.
+func (s *Scanner) writeManifest(finished bool) error {
+	path := s.manifestPath()
+	if path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.buildManifest(finished), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing manifest: %w", err)
+	}
+	return nil
+}
+
+func hashStrings(values []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(values, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,228 @@
+package scanner
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ReflectionMatcher finds reflections of a canary in a response body.
+// Match returns the [start, end) byte span of each reflection, in document
+// order; a reflection need not be len(canary) bytes long, so callers use
+// start to place it and end to check what the server put right after it.
+type ReflectionMatcher interface {
+	Match(body, canary string) [][2]int
+}
+
+// ParseMatcher builds the matcher selected with --match:
+//
+//	exact          the canary verbatim (default)
+//	icase          the canary in any letter case
+//	encoded        the canary verbatim or with any of its characters as an
+//	               HTML entity, %XX, \xXX or \uXXXX escape
+//	fuzzy[:N]      anything within N edits of the canary (default 1), N
+//	               capped below half the canary's length
+//	regex:PATTERN  PATTERN, with {canary} standing for the quoted canary
+func ParseMatcher(spec string) (ReflectionMatcher, error) {
+	name, arg, _ := strings.Cut(spec, ":")
+	switch name {
+	case "", "exact":
+		return exactMatcher{}, nil
+	case "icase":
+		return caseInsensitiveMatcher{}, nil
+	case "encoded":
+		return encodedMatcher{cache: newRegexCache(encodedPattern)}, nil
+	case "fuzzy":
+		maxEdits := 1
+		if arg != "" {
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid fuzzy distance %q", arg)
+			}
+			maxEdits = n
+		}
+		return fuzzyMatcher{maxEdits: maxEdits}, nil
+	case "regex":
+		if !strings.Contains(arg, "{canary}") {
+			return nil, fmt.Errorf("regex matcher %q must contain {canary}", arg)
+		}
+		if _, err := regexp.Compile(strings.ReplaceAll(arg, "{canary}", "x")); err != nil {
+			return nil, fmt.Errorf("invalid regex matcher: %w", err)
+		}
+		return regexMatcher{cache: newRegexCache(func(canary string) string {
+			return strings.ReplaceAll(arg, "{canary}", regexp.QuoteMeta(canary))
+		})}, nil
+	}
+	return nil, fmt.Errorf("invalid matcher %q (use exact, icase, encoded, fuzzy[:N] or regex:PATTERN)", spec)
+}
+
+type exactMatcher struct{}
+
+func (exactMatcher) Match(body, canary string) [][2]int {
+	var spans [][2]int
+	for _, off := range occurrences(body, canary) {
+		spans = append(spans, [2]int{off, off + len(canary)})
+	}
+	return spans
+}
+
+type caseInsensitiveMatcher struct{}
+
+// Match lowercases ASCII only, which keeps byte offsets intact.
+func (caseInsensitiveMatcher) Match(body, canary string) [][2]int {
+	return exactMatcher{}.Match(asciiLower(body), asciiLower(canary))
+}
+
+func asciiLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
+
+// regexCacheSize bounds how many canaries a regexCache holds; a canary is
+// matched against a handful of responses and then never seen again.
+const regexCacheSize = 256
+
+// regexCache keeps the regexps built for recent canaries, so a matcher
+// compiles each canary's pattern once instead of on every Match.
+type regexCache struct {
+	pattern func(canary string) string
+
+	mu       sync.Mutex
+	compiled map[string]*regexp.Regexp
+}
+
+func newRegexCache(pattern func(canary string) string) *regexCache {
+	return &regexCache{pattern: pattern, compiled: make(map[string]*regexp.Regexp)}
+}
+
+func (c *regexCache) get(canary string) *regexp.Regexp {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if re, ok := c.compiled[canary]; ok {
+		return re
+	}
+	if len(c.compiled) >= regexCacheSize {
+		clear(c.compiled)
+	}
+	re := regexp.MustCompile(c.pattern(canary))
+	c.compiled[canary] = re
+	return re
+}
+
+type encodedMatcher struct {
+	cache *regexCache
+}
+
+func (m encodedMatcher) Match(body, canary string) [][2]int {
+	return regexSpans(m.cache.get(canary), body)
+}
+
+func encodedPattern(canary string) string {
+	var pattern strings.Builder
+	for _, r := range canary {
+		fmt.Fprintf(&pattern, `(?:%s|&#0*%d;|(?i:&#x0*%x;|%%%02x|\\x%02x|\\u%04x))`, regexp.QuoteMeta(string(r)), r, r, r, r, r)
+	}
+	return pattern.String()
+}
+
+type regexMatcher struct {
+	cache *regexCache
+}
+
+func (m regexMatcher) Match(body, canary string) [][2]int {
+	return regexSpans(m.cache.get(canary), body)
+}
+
+func regexSpans(re *regexp.Regexp, body string) [][2]int {
+	var spans [][2]int
+	for _, loc := range re.FindAllStringIndex(body, -1) {
+		spans = append(spans, [2]int{loc[0], loc[1]})
+	}
+	return spans
+}
+
+// fuzzyMatcher finds substrings within maxEdits insertions, deletions or
+// substitutions of the canary, for backends that mangle input slightly.
+type fuzzyMatcher struct {
+	maxEdits int
+}
+
+// Match runs Sellers' approximate matching: dist[j] is the smallest edit
+// distance between canary[:j] and a substring of body ending at the current
+// byte, and start[j] is where that substring begins. Of each run of
+// adjacent matching ends only the closest is kept. The distance is capped
+// below half the canary's length: within len(canary) edits every position
+// of any body would match.
+func (m fuzzyMatcher) Match(body, canary string) [][2]int {
+	n := len(canary)
+	maxEdits := min(m.maxEdits, (n-1)/2)
+	dist := make([]int, n+1)
+	start := make([]int, n+1)
+	for j := range dist {
+		dist[j] = j
+	}
+	var spans [][2]int
+	best, bestSpan := maxEdits+1, [2]int{-1, -1}
+	for i := 0; i < len(body); i++ {
+		diag, diagStart := dist[0], i
+		start[0] = i + 1
+		for j := 1; j <= n; j++ {
+			cost := 1
+			if body[i] == canary[j-1] {
+				cost = 0
+			}
+			next, nextStart := diag+cost, diagStart
+			if dist[j]+1 < next {
+				next, nextStart = dist[j]+1, start[j]
+			}
+			if dist[j-1]+1 < next {
+				next, nextStart = dist[j-1]+1, start[j-1]
+			}
+			diag, diagStart = dist[j], start[j]
+			dist[j], start[j] = next, nextStart
+		}
+		if dist[n] <= maxEdits {
+			if dist[n] < best {
+				best, bestSpan = dist[n], [2]int{start[n], i + 1}
+			}
+			continue
+		}
+		if bestSpan[1] >= 0 {
+			spans = append(spans, bestSpan)
+			best, bestSpan = maxEdits+1, [2]int{-1, -1}
+		}
+	}
+	if bestSpan[1] >= 0 {
+		spans = append(spans, bestSpan)
+	}
+	return spans
+}
+
+// reflects reports whether canary reflects in body.
+func (s *Scanner) reflects(body, canary string) bool {
+	return len(s.matcher.Match(body, canary)) > 0
+}
+
+// reflectionBody returns the text a reflection of canary is looked for in:
+// body, or decoded when only the decoded JSON string values of the
+// response contain it. Characters are always classified against the raw
+// body, where JSON escaping such as \" or \u003c still shows.
+func (s *Scanner) reflectionBody(body, decoded, canary string) string {
+	if !s.reflects(body, canary) && decoded != "" && s.reflects(decoded, canary) {
+		return decoded
+	}
+	return body
+}
+
+// reflectsWith reports whether canary reflects in body immediately
+// followed by suffix.
+func (s *Scanner) reflectsWith(body, canary, suffix string) bool {
+	return s.suffixOffset(body, canary, suffix) >= 0
+}
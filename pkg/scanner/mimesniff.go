@@ -0,0 +1,60 @@
+package scanner
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// MimeSniffHint flags a reflection in a response that browsers would not
+// normally render as HTML, but might after content sniffing because the
+// response does not send X-Content-Type-Options: nosniff.
+type MimeSniffHint struct {
+	Type        string   `json:"type"`
+	ContentType string   `json:"content_type"`
+	Conditions  []string `json:"conditions"`
+}
+
+// sniffSafeTypes are rendered as markup by every browser, so reflections in
+// them are ordinary findings.
+var sniffSafeTypes = map[string]bool{
+	"text/html":             true,
+	"application/xhtml+xml": true,
+	"image/svg+xml":         true,
+	"text/xml":              true,
+	"application/xml":       true,
+}
+
+// mimeSniffHint returns a hint when resp reflects canary, as found by
+// matcher, with a non-HTML or missing Content-Type and without nosniff, or
+// nil otherwise.
+func mimeSniffHint(resp *response, matcher ReflectionMatcher, canary string) *MimeSniffHint {
+	if strings.EqualFold(strings.TrimSpace(resp.Header.Get("X-Content-Type-Options")), "nosniff") {
+		return nil
+	}
+	spans := matcher.Match(resp.Body, canary)
+	if len(spans) == 0 {
+		return nil
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if sniffSafeTypes[mediaType] {
+		return nil
+	}
+
+	hint := &MimeSniffHint{Type: "mime-sniffing", ContentType: contentType}
+	switch mediaType {
+	case "":
+		hint.Conditions = append(hint.Conditions, "No Content-Type is sent, so all browsers sniff the body and render it as HTML when it looks like markup")
+	case "text/plain":
+		hint.Conditions = append(hint.Conditions, "Only browsers that sniff text/plain (Internet Explorer, legacy Edge) render it as HTML")
+	default:
+		hint.Conditions = append(hint.Conditions, fmt.Sprintf("Only legacy browsers with content sniffing (Internet Explorer, legacy Edge) render %s as HTML", mediaType))
+	}
+	hint.Conditions = append(hint.Conditions, "The response must be opened as a top-level navigation, not fetched by script")
+	if spans[0][0] > 512 {
+		hint.Conditions = append(hint.Conditions, "Sniffing inspects only the first 512 bytes; the reflection must be moved earlier in the body")
+	}
+	return hint
+}
@@ -0,0 +1,102 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+)
+
+// multibyteProbes are the UTF-8 sequences sent by --multibyte, keyed by the
+// name used in the results. Besides valid sequences of every length they
+// include an overlong encoding of "<" and a lone lead byte, which
+// charset-confused backends decode to ASCII or use to swallow the
+// following character.
+var multibyteProbes = []struct {
+	name string
+	seq  string
+}{
+	{"2-byte", "é"},
+	{"3-byte", "中"},
+	{"4-byte-emoji", "😀"},
+	{"zwj-emoji", "👩‍💻"},
+	{"overlong-lt", "\xc0\xbc"},
+	{"lone-lead-byte", "\xe0"},
+}
+
+// multibyteProbe sends canary+seq+controlTail for each multi-byte sequence
+// and classifies what came back between the canary and the tail:
+//
+//	intact      the bytes came back unmodified
+//	converted   the sequence came back as an HTML character reference
+//	replaced    the sequence became U+FFFD or "?"
+//	decoded     the sequence became a single ASCII character, e.g. "decoded (<)"
+//	mangled     the bytes came back altered, e.g. double-encoded as "Ã©"
+//	stripped    the sequence was removed, the rest of the value kept
+//	truncated   the value was cut off at the sequence, taking the tail with it
+//	blocked     the canary did not come back at all
+func (s *Scanner) multibyteProbe(req *utils.Request, target utils.Target, reflectedInDOM bool) map[string]string {
+	results := make(map[string]string)
+	for _, p := range multibyteProbes {
+		s.resetState(req)
+		canary := s.newCanary(target.URL, target.Param)
+		testTarget, ok := s.probeTarget(req, target.Param, canary+p.seq+controlTail)
+		if !ok {
+			continue
+		}
+
+		var body string
+		var err error
+		if reflectedInDOM {
+			body, err = s.getDOM(testTarget)
+		} else {
+			body, err = s.fetch(testTarget)
+		}
+		if err != nil {
+			continue
+		}
+		results[p.name] = s.classifyMultibyte(body, canary, p.seq)
+	}
+	return results
+}
+
+func (s *Scanner) classifyMultibyte(body, canary, seq string) string {
+	spans := s.matcher.Match(body, canary)
+	if len(spans) == 0 {
+		return "blocked"
+	}
+	rest := body[spans[0][1]:]
+	end := strings.Index(rest, controlTail)
+	if end == -1 {
+		return "truncated"
+	}
+	got := rest[:end]
+	switch {
+	case got == seq:
+		return "intact"
+	case got == "":
+		return "stripped"
+	case isCharRefs(got, seq):
+		return "converted"
+	case strings.Trim(got, "�?") == "" || got == "&#65533;":
+		return "replaced"
+	case len(got) == 1 && got[0] < utf8.RuneSelf:
+		return "decoded (" + got + ")"
+	}
+	return fmt.Sprintf("mangled (%q)", got)
+}
+
+// isCharRefs reports whether got is seq written as decimal or hex HTML
+// character references.
+func isCharRefs(got, seq string) bool {
+	if !utf8.ValidString(seq) {
+		return false
+	}
+	var dec, hex strings.Builder
+	for _, r := range seq {
+		fmt.Fprintf(&dec, "&#%d;", r)
+		fmt.Fprintf(&hex, "&#x%x;", r)
+	}
+	return got == dec.String() || strings.EqualFold(got, hex.String())
+}
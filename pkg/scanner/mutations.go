@@ -0,0 +1,198 @@
+package scanner
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// Mutation is a user-defined transformation of a probe: the probe is run
+// through each encoder in turn and then wrapped in Prefix and Suffix.
+type Mutation struct {
+	Name   string   `yaml:"name" json:"name"`
+	Prefix string   `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+	Suffix string   `yaml:"suffix,omitempty" json:"suffix,omitempty"`
+	Encode []string `yaml:"encode,omitempty" json:"encode,omitempty"`
+}
+
+// mutationEncoders are the encoders a Mutation may name.
+var mutationEncoders = map[string]func(string) string{
+	"url":        url.QueryEscape,
+	"double-url": func(s string) string { return url.QueryEscape(url.QueryEscape(s)) },
+	"url-all":    func(s string) string { return mapBytes(s, "%%%02X") },
+	"html":       func(s string) string { return mapRunes(s, "&#x%x;") },
+	"html-dec":   func(s string) string { return mapRunes(s, "&#%d;") },
+	"html-named": html.EscapeString,
+	"js-unicode": func(s string) string { return mapRunes(s, `\u%04x`) },
+	"js-hex":     func(s string) string { return mapBytes(s, `\x%02x`) },
+	"fullwidth": func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r > 0x20 && r < 0x7f {
+				return r + 0xFEE0
+			}
+			return r
+		}, s)
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+func mapRunes(s, format string) string {
+	var b strings.Builder
+	for _, r := range s {
+		fmt.Fprintf(&b, format, r)
+	}
+	return b.String()
+}
+
+func mapBytes(s, format string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		fmt.Fprintf(&b, format, c)
+	}
+	return b.String()
+}
+
+// Apply returns probe transformed by m.
+func (m Mutation) Apply(probe string) string {
+	for _, name := range m.Encode {
+		probe = mutationEncoders[name](probe)
+	}
+	return m.Prefix + probe + m.Suffix
+}
+
+// LoadMutations reads a --mutations YAML file:
+//
+//	mutations:
+//	  - name: comment-break
+//	    prefix: "<!---->"
+//	  - name: fullwidth
+//	    encode: [fullwidth]
+//	  - name: double-url-in-comment
+//	    prefix: "/*"
+//	    suffix: "*/"
+//	    encode: [double-url]
+func LoadMutations(path string) ([]Mutation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading mutations file: %w", err)
+	}
+	var file struct {
+		Mutations []Mutation `yaml:"mutations"`
+	}
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("parsing mutations file: %w", err)
+	}
+	if len(file.Mutations) == 0 {
+		return nil, fmt.Errorf("mutations file %s has no mutations", path)
+	}
+
+	seen := make(map[string]bool)
+	for i, m := range file.Mutations {
+		if m.Name == "" {
+			return nil, fmt.Errorf("mutation %d has no name", i+1)
+		}
+		if seen[m.Name] {
+			return nil, fmt.Errorf("mutation %s is defined twice", m.Name)
+		}
+		seen[m.Name] = true
+		if m.Prefix == "" && m.Suffix == "" && len(m.Encode) == 0 {
+			return nil, fmt.Errorf("mutation %s changes nothing", m.Name)
+		}
+		for _, name := range m.Encode {
+			if _, ok := mutationEncoders[name]; !ok {
+				return nil, fmt.Errorf("mutation %s: unknown encoder %q (use %s)", m.Name, name, strings.Join(encoderNames(), ", "))
+			}
+		}
+	}
+	return file.Mutations, nil
+}
+
+func encoderNames() []string {
+	names := make([]string, 0, len(mutationEncoders))
+	for name := range mutationEncoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// mutationProbe sends every mutation of each probed character not in
+// allowed and returns, by character, the mutations that made the raw
+// character appear in the reflection. The mutated probe is followed by an
+// end marker so that a character surviving behind a prefix still counts.
+func (s *Scanner) mutationProbe(req *utils.Request, target utils.Target, reflectedInDOM bool, allowed []string) map[string][]string {
+	bypasses := make(map[string][]string)
+	for _, char := range s.chars {
+		if slices.Contains(allowed, char) {
+			continue
+		}
+		for _, m := range s.opts.Mutations {
+			s.resetState(req)
+			canary := s.newCanary(target.URL, target.Param)
+			end := batchMarker(canary, 0)
+			testTarget, ok := s.probeTarget(req, target.Param, canary+m.Apply(char)+end)
+			if !ok {
+				continue
+			}
+
+			var body string
+			var err error
+			if reflectedInDOM {
+				body, err = s.getDOM(testTarget)
+			} else {
+				body, err = s.fetch(testTarget)
+			}
+			if err != nil {
+				continue
+			}
+			if s.mutationBypassed(body, canary, end, m, char) {
+				bypasses[char] = append(bypasses[char], m.Name)
+			}
+		}
+	}
+	return bypasses
+}
+
+// mutationBypassed reports whether char appears raw between an occurrence
+// of canary and the end marker that follows it. The mutation's own prefix
+// and suffix are removed from that region first, so characters they
+// contain, such as the < and > of <!---->, do not count as a bypass.
+func (s *Scanner) mutationBypassed(body, canary, end string, m Mutation, char string) bool {
+	for _, span := range s.matcher.Match(body, canary) {
+		rest := body[span[1]:]
+		j := strings.Index(rest, end)
+		if j < 0 {
+			continue
+		}
+		region := strings.TrimSuffix(strings.TrimPrefix(rest[:j], m.Prefix), m.Suffix)
+		if strings.Contains(region, char) {
+			return true
+		}
+	}
+	return false
+}
+
+// mutationLines renders bypasses as "< via fullwidth, comment-break" lines
+// sorted by character.
+func mutationLines(bypasses map[string][]string) []string {
+	chars := make([]string, 0, len(bypasses))
+	for char := range bypasses {
+		chars = append(chars, char)
+	}
+	sort.Strings(chars)
+	lines := make([]string, 0, len(chars))
+	for _, char := range chars {
+		lines = append(lines, fmt.Sprintf("%s via %s", printableChars([]string{char})[0], strings.Join(bypasses[char], ", ")))
+	}
+	return lines
+}
@@ -0,0 +1,196 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// notifyDigestExamples is how many findings a digest lists by title.
+const notifyDigestExamples = 10
+
+// notifyQueueSize is how many findings can wait to be posted before Write
+// blocks the scan.
+const notifyQueueSize = 256
+
+// WebhookNotifier posts reflected findings to a webhook as they are found.
+// With a digest interval, findings are instead collected and sent as one
+// summary per interval, and whatever is left when the scan ends. The body
+// is a JSON object with a "text" summary, as Slack and Mattermost incoming
+// webhooks expect, and the findings themselves. Findings are posted by a
+// background goroutine so a slow webhook does not hold up the scan.
+type WebhookNotifier struct {
+	url    string
+	digest time.Duration
+	text   *reportText
+	client *http.Client
+
+	mu      sync.Mutex
+	pending []notifyFinding
+	since   time.Time
+	queue   chan notifyFinding
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+type notifyFinding struct {
+	Title    string   `json:"title"`
+	Severity string   `json:"severity"`
+	URL      string   `json:"url"`
+	Param    string   `json:"param,omitempty"`
+	Allowed  []string `json:"allowed,omitempty"`
+}
+
+// NewWebhookNotifier posts findings to webhookURL, batched per digest
+// interval when it is non-zero, with finding titles in lang.
+func NewWebhookNotifier(webhookURL string, digest time.Duration, lang string) (*WebhookNotifier, error) {
+	text, err := reportLanguage(lang)
+	if err != nil {
+		return nil, err
+	}
+	n := &WebhookNotifier{
+		url:    webhookURL,
+		digest: digest,
+		text:   text,
+		client: &http.Client{Timeout: 30 * time.Second},
+		since:  time.Now(),
+	}
+	n.done = make(chan struct{})
+	if digest > 0 {
+		n.stop = make(chan struct{})
+		go n.run()
+	} else {
+		n.queue = make(chan notifyFinding, notifyQueueSize)
+		go n.send()
+	}
+	return n, nil
+}
+
+func (n *WebhookNotifier) Write(output JSONOutput) error {
+	if !output.Reflected {
+		return nil
+	}
+	f := notifyFinding{
+		Title:    findingTitle(output, n.text),
+		Severity: findingSeverity(output),
+		URL:      output.BaseURL,
+		Param:    output.Param,
+		Allowed:  output.Allowed,
+	}
+	if n.digest == 0 {
+		n.queue <- f
+		return nil
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.pending = append(n.pending, f)
+	return nil
+}
+
+// send posts queued findings one at a time until the queue is closed.
+func (n *WebhookNotifier) send() {
+	defer close(n.done)
+	for f := range n.queue {
+		if err := n.post(f.Title, []notifyFinding{f}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error sending notification: %v\n", err)
+		}
+	}
+}
+
+// run sends a digest every interval until Close.
+func (n *WebhookNotifier) run() {
+	defer close(n.done)
+	ticker := time.NewTicker(n.digest)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := n.flush(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error sending notification digest: %v\n", err)
+			}
+		case <-n.stop:
+			return
+		}
+	}
+}
+
+// flush sends the findings collected since the last digest, if any.
+func (n *WebhookNotifier) flush() error {
+	n.mu.Lock()
+	findings, since := n.pending, n.since
+	n.pending, n.since = nil, time.Now()
+	n.mu.Unlock()
+	if len(findings) == 0 {
+		return nil
+	}
+	return n.post(digestText(findings, time.Since(since), n.text), findings)
+}
+
+// digestText summarizes findings by count, host and severity and lists the
+// first few by title, in the language of t.
+func digestText(findings []notifyFinding, window time.Duration, t *reportText) string {
+	hosts := make(map[string]bool)
+	severities := make(map[string]int)
+	for _, f := range findings {
+		if u, err := url.Parse(f.URL); err == nil {
+			hosts[u.Host] = true
+		}
+		severities[f.Severity]++
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, t.Digest, len(findings), len(hosts), window.Round(time.Second))
+	var counts []string
+	for _, sev := range []string{SeverityHigh, SeverityMedium, SeverityLow} {
+		if severities[sev] > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", severities[sev], sev))
+		}
+	}
+	fmt.Fprintf(&b, " (%s)", strings.Join(counts, ", "))
+	for i, f := range findings {
+		if i == notifyDigestExamples {
+			b.WriteString("\n")
+			fmt.Fprintf(&b, t.DigestMore, len(findings)-i)
+			break
+		}
+		fmt.Fprintf(&b, "\n• [%s] %s", f.Severity, f.Title)
+	}
+	return b.String()
+}
+
+func (n *WebhookNotifier) post(text string, findings []notifyFinding) error {
+	body, err := json.Marshal(map[string]any{"text": text, "findings": findings})
+	if err != nil {
+		return err
+	}
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("posting notification: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("notification webhook failed: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// Close waits for queued findings to be posted or, with a digest, stops the
+// digest timer and sends the findings still pending.
+func (n *WebhookNotifier) Close() error {
+	if n.digest == 0 {
+		close(n.queue)
+		<-n.done
+		return nil
+	}
+	close(n.stop)
+	<-n.done
+	return n.flush()
+}
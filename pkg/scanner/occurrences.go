@@ -0,0 +1,69 @@
+package scanner
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// occurrenceSnippetRadius is how many bytes of the page are kept on each
+// side of a reflection in its snippet.
+const occurrenceSnippetRadius = 80
+
+// maxOccurrences caps how many reflections of one canary are reported.
+const maxOccurrences = 100
+
+// Occurrence is one reflection of the canary in a page.
+type Occurrence struct {
+	// Offset is the byte offset of the canary in the response body, or in
+	// the rendered DOM for DOM reflections.
+	Offset  int    `json:"offset"`
+	Context string `json:"context"`
+	Snippet string `json:"snippet"`
+}
+
+// occurrences returns every reflection of canary in body, in document
+// order, up to maxOccurrences.
+func (s *Scanner) occurrences(body, canary string) []Occurrence {
+	var found []Occurrence
+	for _, span := range s.matcher.Match(body, canary) {
+		if len(found) == maxOccurrences {
+			break
+		}
+		found = append(found, Occurrence{
+			Offset:  span[0],
+			Context: reflectionContext(body, span[0]),
+			Snippet: occurrenceSnippet(body, span[0], span[1]),
+		})
+	}
+	return found
+}
+
+// occurrenceSnippet returns body[start:end] widened by
+// occurrenceSnippetRadius bytes on each side, without splitting a UTF-8
+// sequence.
+func occurrenceSnippet(body string, start, end int) string {
+	from := max(0, start-occurrenceSnippetRadius)
+	for from > 0 && from < start && !utf8.RuneStart(body[from]) {
+		from++
+	}
+	to := min(len(body), end+occurrenceSnippetRadius)
+	for to < len(body) && to > end && !utf8.RuneStart(body[to]) {
+		to--
+	}
+	return body[from:to]
+}
+
+// printOccurrences prints where the canary was reflected, one line each.
+func (s *Scanner) printOccurrences(occurrences []Occurrence) {
+	if s.opts.JSONOutput || len(occurrences) == 0 {
+		return
+	}
+	if s.opts.NoColor {
+		fmt.Printf("OCCURRENCES: %d\n", len(occurrences))
+	} else {
+		fmt.Printf("\033[36mOCCURRENCES: %d\033[0m\n", len(occurrences))
+	}
+	for _, o := range occurrences {
+		fmt.Printf("  @%d [%s] %q\n", o.Offset, o.Context, o.Snippet)
+	}
+}
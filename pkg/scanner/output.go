@@ -0,0 +1,301 @@
+package scanner
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OutputWriter receives every result record produced by the scanner.
+type OutputWriter interface {
+	Write(output JSONOutput) error
+	Close() error
+}
+
+// MultiWriter sends every result to each of its writers in turn.
+type MultiWriter []OutputWriter
+
+func (m MultiWriter) Write(output JSONOutput) error {
+	var firstErr error
+	for _, w := range m {
+		if err := w.Write(output); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (m MultiWriter) Close() error {
+	var firstErr error
+	for _, w := range m {
+		if err := w.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// FileWriter writes results as JSON lines to a file, gzip-compressing them
+// on the fly when the path ends in ".gz". When a rotation size or interval
+// is set, the current file is closed and renamed with a timestamp suffix
+// once the limit is reached, and writing continues in a fresh file.
+type FileWriter struct {
+	path           string
+	rotateSize     int64
+	rotateInterval time.Duration
+
+	mu      sync.Mutex
+	file    *os.File
+	counter *countingWriter
+	gz      *gzip.Writer
+	enc     *json.Encoder
+	opened  time.Time
+	records int
+
+	// appending reopens a file written earlier in the same run, as
+	// SplitWriter does after closing an idle partition, instead of
+	// truncating it.
+	appending bool
+}
+
+// NewFileWriter writes results as JSON lines to path, rotating it after
+// rotateSize bytes or rotateInterval when they are non-zero.
+func NewFileWriter(path string, rotateSize int64, rotateInterval time.Duration) (*FileWriter, error) {
+	w := &FileWriter{
+		path:           path,
+		rotateSize:     rotateSize,
+		rotateInterval: rotateInterval,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *FileWriter) open() error {
+	if dir := filepath.Dir(w.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("creating output directory: %w", err)
+		}
+	}
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if w.appending {
+		// A gzip file may hold several members, read back as one stream
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(w.path, flags, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening output file: %w", err)
+	}
+	w.file = f
+	w.counter = &countingWriter{w: f}
+	if fi, err := f.Stat(); err == nil && w.appending {
+		w.counter.n = fi.Size()
+	}
+
+	var out io.Writer = w.counter
+	w.gz = nil
+	if strings.HasSuffix(w.path, ".gz") {
+		w.gz = gzip.NewWriter(w.counter)
+		out = w.gz
+	}
+	w.enc = json.NewEncoder(out)
+	w.enc.SetEscapeHTML(false)
+	w.opened = time.Now()
+	w.records = 0
+	return nil
+}
+
+func (w *FileWriter) closeFile() error {
+	if w.gz != nil {
+		if err := w.gz.Close(); err != nil {
+			w.file.Close()
+			return err
+		}
+	}
+	return w.file.Close()
+}
+
+func (w *FileWriter) rotate() error {
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+	if err := os.Rename(w.path, rotatedName(w.path, time.Now())); err != nil {
+		return fmt.Errorf("rotating output file: %w", err)
+	}
+	return w.open()
+}
+
+func (w *FileWriter) Write(output JSONOutput) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.records > 0 && ((w.rotateSize > 0 && w.counter.n >= w.rotateSize) ||
+		(w.rotateInterval > 0 && time.Since(w.opened) >= w.rotateInterval)) {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+	w.records++
+	return w.enc.Encode(output)
+}
+
+func (w *FileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.closeFile()
+}
+
+// rotatedName inserts a timestamp before the file extensions, turning
+// results.jsonl.gz into results-20240101T150405<nanos>.jsonl.gz.
+func rotatedName(path string, t time.Time) string {
+	dir, base := filepath.Split(path)
+	stem, ext := base, ""
+	if i := strings.Index(base, "."); i > 0 {
+		stem, ext = base[:i], base[i:]
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s-%s%09d%s", stem, t.UTC().Format("20060102T150405"), t.Nanosecond(), ext))
+}
+
+// countingWriter tracks how many bytes have reached the underlying file.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// maxOpenPartitions caps the files a SplitWriter keeps open; the least
+// recently written partition is closed to make room for a new one and
+// reopened for appending when it is written again.
+const maxOpenPartitions = 64
+
+// SplitWriter partitions results across files by host or by UTC hour, each
+// partition being a FileWriter named after the configured output path
+// (results.jsonl.gz becomes results.example.com.jsonl.gz or
+// results.2024010215.jsonl.gz).
+type SplitWriter struct {
+	path           string
+	by             string
+	rotateSize     int64
+	rotateInterval time.Duration
+
+	mu       sync.Mutex
+	writers  map[string]*FileWriter
+	lastUsed map[string]int
+	uses     int
+	// written lists every partition opened so far, open or not.
+	written map[string]bool
+}
+
+// NewSplitWriter partitions results by "host" or "hour" into FileWriters
+// named after path.
+func NewSplitWriter(path, by string, rotateSize int64, rotateInterval time.Duration) (*SplitWriter, error) {
+	if by != "host" && by != "hour" {
+		return nil, fmt.Errorf("invalid output split %q (use host or hour)", by)
+	}
+	return &SplitWriter{
+		path:           path,
+		by:             by,
+		rotateSize:     rotateSize,
+		rotateInterval: rotateInterval,
+		writers:        make(map[string]*FileWriter),
+		lastUsed:       make(map[string]int),
+		written:        make(map[string]bool),
+	}, nil
+}
+
+func (w *SplitWriter) partition(output JSONOutput) string {
+	if w.by == "hour" {
+		return time.Now().UTC().Format("2006010215")
+	}
+	if u, err := url.Parse(output.BaseURL); err == nil && u.Host != "" {
+		return sanitizePathComponent(u.Host)
+	}
+	return "unknown"
+}
+
+func (w *SplitWriter) Write(output JSONOutput) error {
+	key := w.partition(output)
+
+	// The lock is held across the write so a partition is never closed
+	// while another worker is still writing to it
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fw, ok := w.writers[key]
+	if !ok {
+		// Hourly partitions are written in order, so earlier hours can be closed
+		if w.by == "hour" {
+			for k := range w.writers {
+				w.closePartition(k)
+			}
+		}
+		for len(w.writers) >= maxOpenPartitions {
+			w.closePartition(w.leastRecentlyUsed())
+		}
+		fw = &FileWriter{
+			path:           partitionName(w.path, key),
+			rotateSize:     w.rotateSize,
+			rotateInterval: w.rotateInterval,
+			appending:      w.written[key],
+		}
+		if err := fw.open(); err != nil {
+			return err
+		}
+		w.writers[key] = fw
+		w.written[key] = true
+	}
+	w.uses++
+	w.lastUsed[key] = w.uses
+
+	return fw.Write(output)
+}
+
+func (w *SplitWriter) closePartition(key string) {
+	w.writers[key].Close()
+	delete(w.writers, key)
+	delete(w.lastUsed, key)
+}
+
+func (w *SplitWriter) leastRecentlyUsed() string {
+	oldest := ""
+	for k := range w.writers {
+		if oldest == "" || w.lastUsed[k] < w.lastUsed[oldest] {
+			oldest = k
+		}
+	}
+	return oldest
+}
+
+func (w *SplitWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var firstErr error
+	for _, fw := range w.writers {
+		if err := fw.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// partitionName inserts key before the file extensions of path.
+func partitionName(path, key string) string {
+	dir, base := filepath.Split(path)
+	stem, ext := base, ""
+	if i := strings.Index(base, "."); i > 0 {
+		stem, ext = base[:i], base[i:]
+	}
+	return filepath.Join(dir, stem+"."+key+ext)
+}
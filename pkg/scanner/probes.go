@@ -0,0 +1,87 @@
+package scanner
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+)
+
+// Probe records a single character probe: what was sent, how the server
+// answered and how the reflection was classified.
+type Probe struct {
+	Char string `json:"char"`
+	URL  string `json:"url"`
+	// Sent is the request target as written on the request line, after
+	// the HTTP client's or, for browser probes, Chrome's own escaping.
+	Sent string `json:"sent,omitempty"`
+	// Status is the HTTP status code, or 0 for probes rendered in the
+	// headless browser.
+	Status    int   `json:"status,omitempty"`
+	LatencyMs int64 `json:"latency_ms"`
+	// ConnectMs, TLSMs and FirstByteMs break LatencyMs down into the TCP
+	// connect, the TLS handshake and the wait until the first response
+	// byte; the first two are absent on a reused connection.
+	ConnectMs   int64 `json:"connect_ms,omitempty"`
+	TLSMs       int64 `json:"tls_ms,omitempty"`
+	FirstByteMs int64 `json:"first_byte_ms,omitempty"`
+	// Classification is allowed, converted, stripped, blocked or error.
+	Classification string `json:"classification"`
+	Encoding       string `json:"encoding,omitempty"`
+	// EvidenceOffset is the byte offset in the response body where the
+	// character, or its converted form, follows the canary; -1 if it
+	// does not.
+	EvidenceOffset int    `json:"evidence_offset"`
+	Error          string `json:"error,omitempty"`
+	// WAF names the WAF whose block page answered the probe.
+	WAF string `json:"waf,omitempty"`
+	// Batched is set when the character was classified from the single
+	// --batch request rather than a request of its own.
+	Batched bool `json:"batched,omitempty"`
+}
+
+// sendProbe fetches target, through the headless browser when the
+// reflection was found in the DOM, and returns the body together with the
+// request details of the probe.
+func (s *Scanner) sendProbe(target utils.Target, char string, reflectedInDOM bool) (string, Probe, error) {
+	probe := Probe{Char: char, URL: target.URL, EvidenceOffset: -1}
+	start := time.Now()
+	var body string
+	var err error
+	if reflectedInDOM {
+		body, probe.Sent, err = s.getDOMSent(target)
+	} else {
+		if u, err := url.Parse(target.URL); err == nil {
+			probe.Sent = u.RequestURI()
+		}
+		var resp *response
+		if resp, err = s.fetchResponse(target); err == nil {
+			body = resp.Body
+			probe.Status = resp.StatusCode
+			probe.ConnectMs = resp.Timings.ConnectMs
+			probe.TLSMs = resp.Timings.TLSMs
+			probe.FirstByteMs = resp.Timings.FirstByteMs
+			if probe.WAF = detectWAF(resp); probe.WAF != "" {
+				s.wafPacer.Blocked(target.URL)
+			}
+		}
+	}
+	probe.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		probe.Classification = "error"
+		probe.Error = err.Error()
+	}
+	return body, probe, err
+}
+
+// suffixOffset returns the offset in body at which suffix immediately
+// follows a reflection of canary, or -1.
+func (s *Scanner) suffixOffset(body, canary, suffix string) int {
+	for _, span := range s.matcher.Match(body, canary) {
+		if strings.HasPrefix(body[span[1]:], suffix) {
+			return span[1]
+		}
+	}
+	return -1
+}
@@ -0,0 +1,55 @@
+package scanner
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/proxy"
+)
+
+// configureProxy routes tr through the proxy at rawURL. HTTP(S) proxies use
+// the transport's Proxy hook; socks5:// and socks5h:// proxies, optionally
+// with user:pass credentials, replace its dialer and connect through forward.
+func configureProxy(tr *http.Transport, rawURL string, forward *net.Dialer) error {
+	proxyURL, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL: %w", err)
+	}
+
+	switch proxyURL.Scheme {
+	case "socks5", "socks5h":
+		dialer, err := proxy.FromURL(proxyURL, forward)
+		if err != nil {
+			return fmt.Errorf("invalid SOCKS proxy: %w", err)
+		}
+		contextDialer, ok := dialer.(proxy.ContextDialer)
+		if !ok {
+			return fmt.Errorf("SOCKS proxy dialer does not support contexts")
+		}
+		tr.Proxy = nil
+		tr.DialContext = contextDialer.DialContext
+	case "http", "https":
+		tr.Proxy = http.ProxyURL(proxyURL)
+	default:
+		return fmt.Errorf("unsupported proxy scheme %q (use http, https, socks5 or socks5h)", proxyURL.Scheme)
+	}
+	return nil
+}
+
+// browserProxy converts rawURL to a Chrome --proxy-server value. Chrome
+// cannot authenticate to SOCKS proxies, so credentials are dropped and
+// dropped reports it, and it always resolves names through a SOCKS5 proxy,
+// so socks5h becomes socks5.
+func browserProxy(rawURL string) (server string, dropped bool) {
+	proxyURL, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL, false
+	}
+	switch proxyURL.Scheme {
+	case "socks5", "socks5h":
+		return "socks5://" + proxyURL.Host, proxyURL.User != nil
+	}
+	return rawURL, false
+}
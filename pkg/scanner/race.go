@@ -0,0 +1,22 @@
+package scanner
+
+import "github.com/bytes-Knight/xssrecon/pkg/utils"
+
+// domResult is the outcome of a browser navigation started in the background.
+type domResult struct {
+	body string
+	err  error
+}
+
+// startDOM renders target in the browser without waiting for it. Race mode
+// uses it to overlap the navigation with the HTTP request, so the DOM result
+// is ready sooner when the HTTP response turns out not to reflect. The
+// channel is buffered, so an unused result doesn't leak the goroutine.
+func (s *Scanner) startDOM(target utils.Target) <-chan domResult {
+	ch := make(chan domResult, 1)
+	go func() {
+		body, err := s.getDOM(target)
+		ch <- domResult{body: body, err: err}
+	}()
+	return ch
+}
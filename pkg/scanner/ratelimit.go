@@ -0,0 +1,118 @@
+package scanner
+
+import (
+	"math/rand/v2"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// rateLimiter spaces out requests to at most one per interval. Callers
+// reserve the next free slot and sleep until it arrives, so it is safe to
+// share between workers.
+type rateLimiter struct {
+	interval time.Duration
+	mu       sync.Mutex
+	next     time.Time
+}
+
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+func (l *rateLimiter) Wait() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+// hostLimiter enforces a global request rate plus a separate rate for each
+// host, covering both HTTP requests and browser navigations. It also keeps a
+// randomized delay between the requests of each worker and between any two
+// requests to the same host.
+type hostLimiter struct {
+	global  *rateLimiter
+	perHost float64
+	delay   time.Duration
+	jitter  time.Duration
+
+	mu       sync.Mutex
+	hosts    map[string]*rateLimiter
+	hostNext map[string]time.Time
+}
+
+func newHostLimiter(global, perHost float64, delay, jitter time.Duration) *hostLimiter {
+	if global <= 0 && perHost <= 0 && delay <= 0 && jitter <= 0 {
+		return nil
+	}
+	return &hostLimiter{
+		global:   newRateLimiter(global),
+		perHost:  perHost,
+		delay:    delay,
+		jitter:   jitter,
+		hosts:    make(map[string]*rateLimiter),
+		hostNext: make(map[string]time.Time),
+	}
+}
+
+// Wait blocks until a request to rawURL is allowed.
+func (h *hostLimiter) Wait(rawURL string) {
+	if h == nil {
+		return
+	}
+	host := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		host = u.Host
+	}
+	if h.delay > 0 || h.jitter > 0 {
+		h.waitDelay(host)
+	}
+	if h.perHost > 0 {
+		h.mu.Lock()
+		l, ok := h.hosts[host]
+		if !ok {
+			l = newRateLimiter(h.perHost)
+			h.hosts[host] = l
+		}
+		h.mu.Unlock()
+		l.Wait()
+	}
+	h.global.Wait()
+}
+
+// waitDelay sleeps for the configured delay plus a random share of the
+// jitter, which spaces out the calling worker's requests, then waits until
+// the same spacing has passed since the last request to host.
+func (h *hostLimiter) waitDelay(host string) {
+	d := h.delay
+	if h.jitter > 0 {
+		d += rand.N(h.jitter)
+	}
+	time.Sleep(d)
+
+	h.mu.Lock()
+	now := time.Now()
+	next := h.hostNext[host]
+	if next.Before(now) {
+		next = now
+	}
+	h.hostNext[host] = next.Add(d)
+	h.mu.Unlock()
+
+	time.Sleep(next.Sub(now))
+}
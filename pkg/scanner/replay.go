@@ -0,0 +1,261 @@
+package scanner
+
+import (
+	"cmp"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+)
+
+// ReplayResult is the outcome of re-verifying a stored finding.
+type ReplayResult struct {
+	BaseURL      string   `json:"baseurl"`
+	Param        string   `json:"param,omitempty"`
+	Fingerprint  string   `json:"fingerprint,omitempty"`
+	Status       string   `json:"status"`
+	Reflected    bool     `json:"reflected"`
+	StillAllowed []string `json:"still_allowed"`
+	NowBlocked   []string `json:"now_blocked"`
+	// Bypasses are the payloads suggested for the finding that were sent
+	// again and StillBypassed those that still reflect intact.
+	Bypasses      []string `json:"bypasses,omitempty"`
+	StillBypassed []string `json:"still_bypassed,omitempty"`
+	Error         string   `json:"error,omitempty"`
+	// Verdict is set by --verify-fix to "pass" or "fail".
+	Verdict string `json:"verdict,omitempty"`
+}
+
+// FixVerified reports whether the finding no longer reproduces: either the
+// canary stopped reflecting or the payloads suggested for its context no
+// longer come back intact. Findings recorded without suggestions pass when
+// none of the previously allowed characters come back raw, and fail if they
+// still reflect with nothing else to check.
+func (r ReplayResult) FixVerified() bool {
+	switch r.Status {
+	case ReplayFixed:
+		return true
+	case ReplayError:
+		return false
+	}
+	if len(r.Bypasses) > 0 {
+		return len(r.StillBypassed) == 0
+	}
+	if len(r.StillAllowed)+len(r.NowBlocked) > 0 {
+		return len(r.StillAllowed) == 0
+	}
+	return false
+}
+
+// RecordedRequest is the part of a request template, besides its URL,
+// needed to send it again.
+type RecordedRequest struct {
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Cookie  string            `json:"cookie,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	// InjectHeaders lists the headers probed as injection points.
+	InjectHeaders []string `json:"inject_headers,omitempty"`
+}
+
+// recordRequest returns what of req replay needs, with the method and body
+// template given on the command line filled in, so that a finding replays
+// the same request whatever flags replay is run with.
+func (s *Scanner) recordRequest(req *utils.Request) *RecordedRequest {
+	return &RecordedRequest{
+		Method:        cmp.Or(req.Method, s.opts.Method, "GET"),
+		Headers:       req.Headers,
+		Cookie:        req.Cookie,
+		Body:          cmp.Or(req.Body, s.opts.Data),
+		InjectHeaders: req.InjectHeaders,
+	}
+}
+
+// replayRequest rebuilds the request template of a finding. Records
+// written before requests were stored replay as a GET of the URL.
+func replayRequest(finding JSONOutput) *utils.Request {
+	req := &utils.Request{Method: "GET", URL: finding.Processing}
+	if r := finding.Request; r != nil {
+		req.Method = r.Method
+		req.Headers = r.Headers
+		req.Cookie = r.Cookie
+		req.Body = r.Body
+		req.InjectHeaders = r.InjectHeaders
+	}
+	return req
+}
+
+// Replay statuses.
+const (
+	ReplayStillValid     = "still-valid"
+	ReplayPartiallyFixed = "partially-fixed"
+	ReplayFixed          = "fixed"
+	ReplayError          = "error"
+)
+
+// LoadFindings reads result records written by --json or --output. Both
+// indented JSON streams and JSON lines are accepted, optionally gzipped.
+// Records of another major schema version, or without one, are rejected.
+func LoadFindings(path string) ([]JSONOutput, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening findings: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("opening findings: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var findings []JSONOutput
+	dec := json.NewDecoder(r)
+	for {
+		var rec JSONOutput
+		if err := dec.Decode(&rec); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("parsing findings: %w", err)
+		}
+		if err := checkSchemaVersion(rec.SchemaVersion); err != nil {
+			return nil, fmt.Errorf("parsing findings: record %d: %w", len(findings)+1, err)
+		}
+		findings = append(findings, rec)
+	}
+	return findings, nil
+}
+
+// Replay re-sends the minimal probes needed to confirm a stored finding:
+// the bare canary, then each character that was previously allowed and each
+// payload suggested for the reflection's context.
+func (s *Scanner) Replay(finding JSONOutput) ReplayResult {
+	result := ReplayResult{
+		BaseURL:      finding.BaseURL,
+		Param:        finding.Param,
+		Fingerprint:  finding.Fingerprint,
+		StillAllowed: []string{},
+		NowBlocked:   []string{},
+	}
+	req := replayRequest(finding)
+
+	canary := s.newCanary(finding.BaseURL, finding.Param)
+	target, ok := s.probeTarget(req, finding.Param, canary)
+	if !ok {
+		result.Status = ReplayError
+		result.Error = "injection point no longer present in input"
+		return result
+	}
+	reflected, err := s.fetchReflects(target, canary, "")
+	if err != nil {
+		result.Status = ReplayError
+		result.Error = err.Error()
+		return result
+	}
+	if !reflected {
+		result.Status = ReplayFixed
+		return result
+	}
+	result.Reflected = true
+
+	for _, char := range finding.Allowed {
+		canary := s.newCanary(finding.BaseURL, finding.Param)
+		testTarget, ok := s.probeTarget(req, finding.Param, canary+char)
+		if !ok {
+			continue
+		}
+		if ok, err := s.fetchReflects(testTarget, canary, char); err == nil && ok {
+			result.StillAllowed = append(result.StillAllowed, char)
+		} else {
+			result.NowBlocked = append(result.NowBlocked, char)
+		}
+	}
+
+	for _, sg := range finding.Suggestions {
+		canary := s.newCanary(finding.BaseURL, finding.Param)
+		testTarget, ok := s.probeTarget(req, finding.Param, canary+sg.Payload)
+		if !ok {
+			continue
+		}
+		result.Bypasses = append(result.Bypasses, sg.Payload)
+		if ok, err := s.fetchReflects(testTarget, canary, sg.Payload); err == nil && ok {
+			result.StillBypassed = append(result.StillBypassed, sg.Payload)
+		}
+	}
+
+	result.Status = ReplayStillValid
+	if len(result.NowBlocked) > 0 && len(result.StillBypassed) == 0 {
+		result.Status = ReplayPartiallyFixed
+	}
+	return result
+}
+
+// fetchReflects fetches target and reports whether canary followed by
+// suffix appears in the response, falling back to the rendered DOM for GET
+// requests.
+func (s *Scanner) fetchReflects(target utils.Target, canary, suffix string) (bool, error) {
+	body, err := s.fetch(target)
+	if err != nil {
+		return false, err
+	}
+	if s.reflectsWith(body, canary, suffix) {
+		return true, nil
+	}
+	if !isGet(target) {
+		return false, nil
+	}
+	body, err = s.getDOM(target)
+	if err != nil {
+		return false, err
+	}
+	return s.reflectsWith(body, canary, suffix), nil
+}
+
+// PrintReplay prints a replay result in the configured output format.
+func (s *Scanner) PrintReplay(result ReplayResult) {
+	if s.opts.JSONOutput && !s.filterText {
+		jsonBytes, _ := json.Marshal(result)
+		fmt.Println(string(jsonBytes))
+		return
+	}
+
+	label := strings.ToUpper(result.Status)
+	if result.Verdict != "" {
+		label = strings.ToUpper(result.Verdict)
+	}
+	line := fmt.Sprintf("%s: %s", label, result.BaseURL)
+	if result.Param != "" {
+		line += fmt.Sprintf(" [%s]", result.Param)
+	}
+	if result.Reflected {
+		line += fmt.Sprintf(" still allowed: %v now blocked: %v", result.StillAllowed, result.NowBlocked)
+		if len(result.Bypasses) > 0 {
+			line += fmt.Sprintf(" still bypassed: %v", result.StillBypassed)
+		}
+	}
+	if result.Error != "" {
+		line += " (" + result.Error + ")"
+	}
+	if s.opts.NoColor {
+		fmt.Println(line)
+		return
+	}
+	color := "\033[91m"
+	switch {
+	case result.Verdict == "pass", result.Verdict == "" && result.Status == ReplayFixed:
+		color = "\033[92m"
+	case result.Verdict == "" && result.Status == ReplayPartiallyFixed:
+		color = "\033[33m"
+	}
+	fmt.Printf("%s%s\033[0m\n", color, line)
+}
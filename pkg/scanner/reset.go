@@ -0,0 +1,39 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+)
+
+// originalTarget is req as given, with any {payload} placeholder emptied.
+func (s *Scanner) originalTarget(req *utils.Request) utils.Target {
+	method := s.opts.Method
+	if req.Method != "" {
+		method = req.Method
+	}
+	data := s.opts.Data
+	if req.Body != "" {
+		data = req.Body
+	}
+	return utils.Target{
+		URL:     strings.ReplaceAll(req.URL, "{payload}", ""),
+		Method:  method,
+		Body:    strings.ReplaceAll(data, "{payload}", ""),
+		Headers: req.Headers,
+		Cookies: requestCookies(req),
+	}
+}
+
+// resetState re-sends the unmodified request before a probe when
+// --reset-state is set, so endpoints that remember the last value (recent
+// searches, saved form drafts) cannot echo an earlier probe into this one.
+func (s *Scanner) resetState(req *utils.Request) {
+	if !s.opts.ResetState {
+		return
+	}
+	if _, err := s.fetch(s.originalTarget(req)); err != nil && s.opts.Verbose {
+		fmt.Printf("Error resetting state: %v\n", err)
+	}
+}
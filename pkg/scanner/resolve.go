@@ -0,0 +1,175 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// dialFunc matches http.Transport.DialContext.
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// parseResolve parses --resolve entries into a map to IPs. Entries are
+// host:ip, keyed by host, or curl's host:port:ip, keyed by host:port and
+// applying to that port only.
+func parseResolve(entries []string) (map[string]string, error) {
+	overrides := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		host, rest, ok := strings.Cut(entry, ":")
+		key := strings.ToLower(host)
+		if port, ip, found := strings.Cut(rest, ":"); found && validPort(port) && net.ParseIP(strings.Trim(ip, "[]")) != nil {
+			key, rest = net.JoinHostPort(key, port), ip
+		}
+		ip := strings.Trim(rest, "[]")
+		if !ok || host == "" || net.ParseIP(ip) == nil {
+			return nil, fmt.Errorf("invalid --resolve entry %q (use host:ip or host:port:ip)", entry)
+		}
+		overrides[key] = ip
+	}
+	return overrides, nil
+}
+
+func validPort(s string) bool {
+	n, err := strconv.Atoi(s)
+	return err == nil && n > 0 && n <= 65535
+}
+
+// newDialer returns a dialer that resolves names through dnsServer when it
+// is set, or the system resolver otherwise.
+func newDialer(dnsServer string) *net.Dialer {
+	dialer := &net.Dialer{}
+	if dnsServer == "" {
+		return dialer
+	}
+	if _, _, err := net.SplitHostPort(dnsServer); err != nil {
+		dnsServer = net.JoinHostPort(dnsServer, "53")
+	}
+	dialer.Resolver = &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, dnsServer)
+		},
+	}
+	return dialer
+}
+
+// overrideDial wraps dial so connections to hosts in overrides go to the
+// mapped IP instead. TLS still uses the original host name for SNI and
+// certificate checks, as the address is only swapped at dial time.
+func overrideDial(dial dialFunc, overrides map[string]string) dialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if host, port, err := net.SplitHostPort(addr); err == nil {
+			host = strings.ToLower(host)
+			if ip, ok := overrides[net.JoinHostPort(host, port)]; ok {
+				addr = net.JoinHostPort(ip, port)
+			} else if ip, ok := overrides[host]; ok {
+				addr = net.JoinHostPort(ip, port)
+			}
+		}
+		return dial(ctx, network, addr)
+	}
+}
+
+// hostResolverRules converts overrides to a Chrome --host-resolver-rules
+// value. Chrome matches a rule's pattern against both host and host:port.
+func hostResolverRules(overrides map[string]string) string {
+	rules := make([]string, 0, len(overrides))
+	for host, ip := range overrides {
+		if strings.Contains(ip, ":") {
+			ip = "[" + ip + "]"
+		}
+		rules = append(rules, "MAP "+host+" "+ip)
+	}
+	return strings.Join(rules, ", ")
+}
+
+// resolveProxyWarning describes the name resolution options that a proxy
+// makes ineffective, or returns "". An HTTP proxy resolves every name
+// itself; a SOCKS proxy is sent names, so only the HTTP client's --resolve
+// overrides, swapped in before dialing, still apply.
+func resolveProxyWarning(rawProxy string, overrides bool, dnsServer bool) string {
+	if rawProxy == "" || !overrides && !dnsServer {
+		return ""
+	}
+	proxyURL, err := url.Parse(rawProxy)
+	if err != nil {
+		return ""
+	}
+	switch proxyURL.Scheme {
+	case "socks5", "socks5h":
+		switch {
+		case overrides && dnsServer:
+			return "WARNING: --dns-server does not apply through a SOCKS proxy, and --resolve only applies to HTTP requests, not the headless browser"
+		case overrides:
+			return "WARNING: --resolve does not apply to the headless browser through a SOCKS proxy"
+		}
+		return "WARNING: --dns-server does not apply through a SOCKS proxy, which is sent host names"
+	}
+	return "WARNING: --resolve and --dns-server do not apply through an HTTP proxy, which resolves host names itself"
+}
+
+// startDialProxy serves a local HTTP proxy whose upstream connections are
+// made with dial, so a browser pointed at it resolves names as the HTTP
+// client does. CONNECT tunnels carry HTTPS; plain HTTP requests are
+// forwarded.
+func startDialProxy(dial dialFunc) (net.Listener, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("starting browser DNS proxy: %w", err)
+	}
+	forward := &http.Transport{DialContext: dial}
+	go http.Serve(ln, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			forwardRequest(w, r, forward)
+			return
+		}
+		upstream, err := dial(r.Context(), "tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			upstream.Close()
+			http.Error(w, "hijacking not supported", http.StatusInternalServerError)
+			return
+		}
+		client, buf, err := hj.Hijack()
+		if err != nil {
+			upstream.Close()
+			return
+		}
+		client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n"))
+		go func() {
+			io.Copy(upstream, buf)
+			upstream.Close()
+		}()
+		io.Copy(client, upstream)
+		client.Close()
+	}))
+	return ln, nil
+}
+
+// forwardRequest sends a proxied plain HTTP request on through forward and
+// copies the response back.
+func forwardRequest(w http.ResponseWriter, r *http.Request, forward *http.Transport) {
+	r.RequestURI = ""
+	r.Header.Del("Proxy-Connection")
+	resp, err := forward.RoundTrip(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	for k, v := range resp.Header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
@@ -0,0 +1,125 @@
+package scanner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+)
+
+// convertedProbe is a character probe whose reflection came back encoded,
+// queued for the context-sensitivity re-test.
+type convertedProbe struct {
+	char   string
+	conv   string
+	canary string
+	target utils.Target
+	// body is the response the probe was classified on.
+	body string
+}
+
+// retestConverted checks whether each converted character is only encoded
+// harmlessly. An HTML character reference inside an event handler attribute
+// or a javascript: URL is decoded by the HTML parser before the script
+// sees it, so there the character is as good as raw. Probes are judged on
+// the raw HTTP response: the probe's own when it was fetched over HTTP, a
+// fresh one when it was rendered in the browser, since serialized DOM text
+// no longer shows which characters the server encoded. It returns the
+// characters that should be upgraded to allowed, labelled with that
+// context, and the probes that stay converted.
+func (s *Scanner) retestConverted(probes []convertedProbe, reflectedInDOM bool) (upgraded []string, stillConverted []convertedProbe) {
+	for _, p := range probes {
+		body := p.body
+		if reflectedInDOM {
+			var err error
+			if body, err = s.fetch(p.target); err != nil {
+				if s.opts.Verbose {
+					fmt.Printf("Error re-testing converted character %s: %v\n", p.char, err)
+				}
+				stillConverted = append(stillConverted, p)
+				continue
+			}
+		}
+
+		if context := s.decodedIntoScript(body, p.canary, p.char); context != "" {
+			upgraded = append(upgraded, fmt.Sprintf("%s (%s)", p.char, context))
+		} else {
+			stillConverted = append(stillConverted, p)
+		}
+	}
+	return upgraded, stillConverted
+}
+
+// decodedIntoScript returns "event-handler" or "javascript-url" when canary
+// is reflected in body followed by an HTML character reference of char
+// inside an attribute whose value is run as script, or "" otherwise.
+func (s *Scanner) decodedIntoScript(body, canary, char string) string {
+	entities := htmlEntities(char)
+	for _, span := range s.matcher.Match(body, canary) {
+		if !slices.ContainsFunc(entities, func(f conversionForm) bool { return strings.HasPrefix(body[span[1]:], f.text) }) {
+			continue
+		}
+		name, value, ok := enclosingAttribute(body, span[0])
+		switch {
+		case !ok:
+		case strings.HasPrefix(name, "on"):
+			return "event-handler"
+		case urlAttributes[name] && strings.HasPrefix(strings.ToLower(strings.TrimSpace(value)), "javascript:"):
+			return "javascript-url"
+		}
+	}
+	return ""
+}
+
+// enclosingAttribute returns the lowercased name of the attribute whose
+// value offset off of body lies in, and the value up to off.
+func enclosingAttribute(body string, off int) (name, value string, ok bool) {
+	before := body[:off]
+	lt, gt := strings.LastIndex(before, "<"), strings.LastIndex(before, ">")
+	if lt <= gt {
+		return "", "", false
+	}
+	tag := strings.ToLower(before[lt:])
+
+	// The value starts after the opening quote, or after the "=" of an
+	// unquoted value; either way the name ends before that "="
+	var nameEnd, valueStart int
+	var quote byte
+	for i := 0; i < len(tag); i++ {
+		switch c := tag[i]; {
+		case quote == 0 && (c == '"' || c == '\''):
+			quote, nameEnd, valueStart = c, i, i+1
+		case c == quote:
+			quote = 0
+		}
+	}
+	if quote == 0 {
+		token := strings.LastIndexAny(tag, " \t\n") + 1
+		eq := strings.Index(tag[token:], "=")
+		if eq < 0 {
+			return "", "", false
+		}
+		nameEnd = token + eq + 1
+		valueStart = nameEnd
+	}
+
+	attr := strings.TrimRight(tag[:nameEnd], " \t\n")
+	if !strings.HasSuffix(attr, "=") {
+		return "", "", false
+	}
+	attr = strings.TrimRight(strings.TrimSuffix(attr, "="), " \t\n")
+	i := strings.LastIndexFunc(attr, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == ':')
+	})
+	return attr[i+1:], tag[valueStart:], true
+}
+
+func containsProbe(probes []convertedProbe, char string) bool {
+	for _, p := range probes {
+		if p.char == char {
+			return true
+		}
+	}
+	return false
+}
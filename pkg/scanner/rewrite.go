@@ -0,0 +1,91 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+)
+
+// RewriteRule replaces every match of Pattern in an input URL with
+// Replacement, which may refer to capture groups as $1 or ${name}.
+type RewriteRule struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// rewriteSeparator splits a rules file line into pattern and replacement.
+const rewriteSeparator = " => "
+
+// LoadRewriteRules reads a --rewrite file: one "regex => replacement" rule
+// per line, with blank lines and lines starting with # ignored. The
+// replacement may be empty to strip the match, e.g.
+//
+//	^https://www\.example\.com/ => https://staging.example.com/
+//	/(en|de|fr)-[a-z]{2}/ => /
+//	^(https?://[^/:]+)(:\d+)?/ => ${1}:8443/
+func LoadRewriteRules(path string) ([]RewriteRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening rewrite rules: %w", err)
+	}
+	defer f.Close()
+
+	var rules []RewriteRule
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pattern, replacement, ok := strings.Cut(line, rewriteSeparator)
+		if !ok {
+			// A rule with an empty replacement loses its trailing space
+			pattern, ok = strings.CutSuffix(line, strings.TrimRight(rewriteSeparator, " "))
+		}
+		if !ok {
+			return nil, fmt.Errorf("rewrite rules line %d: expected \"regex%sreplacement\"", n, rewriteSeparator)
+		}
+		re, err := regexp.Compile(strings.TrimSpace(pattern))
+		if err != nil {
+			return nil, fmt.Errorf("rewrite rules line %d: %w", n, err)
+		}
+		rules = append(rules, RewriteRule{Pattern: re, Replacement: strings.TrimSpace(replacement)})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading rewrite rules: %w", err)
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("rewrite rules file %s has no rules", path)
+	}
+	return rules, nil
+}
+
+// rewriteURL applies every rule in order, each to the result of the last.
+func rewriteURL(rules []RewriteRule, rawURL string) string {
+	for _, r := range rules {
+		rawURL = r.Pattern.ReplaceAllString(rawURL, r.Replacement)
+	}
+	return rawURL
+}
+
+// rewriteRequest returns req with its URL rewritten by the --rewrite rules,
+// leaving the caller's request untouched.
+func (s *Scanner) rewriteRequest(req *utils.Request) *utils.Request {
+	if len(s.opts.Rewrite) == 0 {
+		return req
+	}
+	rewritten := rewriteURL(s.opts.Rewrite, req.URL)
+	if rewritten == req.URL {
+		return req
+	}
+	if s.opts.Verbose && !s.opts.JSONOutput {
+		fmt.Printf("REWRITTEN: %s -> %s\n", req.URL, rewritten)
+	}
+	r := *req
+	r.URL = rewritten
+	return &r
+}
@@ -1,354 +1,1793 @@
-package scanner
-
-import (
-	"context"
-	"crypto/tls"
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-	"net/url"
-	"strings"
-	"time"
-
-	"github.com/bytes-Knight/xssrecon/pkg/utils"
-	"github.com/chromedp/cdproto/network"
-	"github.com/chromedp/chromedp"
-)
-
-var specialChars = []string{`'`, `"`, `<`, `>`, `(`, `)`, "`", `{`, `}`, `/`, `\`, `;`}
-
-var conversions = map[string]string{
-	"'":  "&#039;",
-	`"`:  "&quot;",
-	"<":  "&lt;",
-	">":  "&gt;",
-}
-
-type Options struct {
-	UserAgent       string
-	Timeout         int
-	SkipSpecialChar bool
-	NoColor         bool
-	Verbose         bool
-	JSONOutput      bool
-	Proxy           string
-	Concurrency     int
-	VerifySSL       bool
-}
-
-type JSONOutput struct {
-	Processing string         `json:"processing"`
-	BaseURL    string         `json:"baseurl"`
-	Reflected  bool           `json:"reflected"`
-	Allowed    []string       `json:"allowed"`
-	Blocked    []string       `json:"blocked"`
-	Converted  []string       `json:"converted"`
-	Count      map[string]int `json:"count"`
-}
-
-type Scanner struct {
-	opts       Options
-	client     *http.Client
-	domScanner *DOMScanner
-}
-
-func NewScanner(opts Options) (*Scanner, error) {
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: !opts.VerifySSL},
-	}
-
-	if opts.Proxy != "" {
-		proxyURL, err := url.Parse(opts.Proxy)
-		if err != nil {
-			return nil, fmt.Errorf("invalid proxy URL: %w", err)
-		}
-		tr.Proxy = http.ProxyURL(proxyURL)
-	}
-
-	client := &http.Client{
-		Transport: tr,
-		Timeout:   time.Duration(opts.Timeout) * time.Second,
-	}
-
-	domScanner, err := NewDOMScanner(opts.Timeout, opts.Proxy, opts.VerifySSL)
-	if err != nil {
-		return nil, err
-	}
-
-	return &Scanner{
-		opts:       opts,
-		client:     client,
-		domScanner: domScanner,
-	}, nil
-}
-
-func (s *Scanner) Close() {
-	if s.domScanner != nil {
-		s.domScanner.Close()
-	}
-}
-
-func (s *Scanner) Scan(inputURL string) {
-	if !s.opts.JSONOutput {
-		if s.opts.NoColor {
-			fmt.Printf("\nPROCESSING: %s\n", inputURL)
-		} else {
-			fmt.Printf("\n\033[96mPROCESSING: %s\033[0m\n", inputURL)
-		}
-	}
-
-	baseURLs, err := utils.GenerateTargetURLs(inputURL, "rix4uni")
-	if err != nil {
-		if s.opts.Verbose {
-			fmt.Printf("Error generating target URLs: %v\n", err)
-		}
-		return
-	}
-
-	for _, baseURL := range baseURLs {
-		s.processBaseURL(inputURL, baseURL)
-	}
-}
-
-func (s *Scanner) processBaseURL(inputURL, baseURL string) {
-	var output JSONOutput
-	output.Processing = inputURL
-	output.BaseURL = baseURL
-
-	if !s.opts.JSONOutput {
-		if s.opts.NoColor {
-			fmt.Printf("BASEURL: %s\n", baseURL)
-		} else {
-			fmt.Printf("\033[94mBASEURL: %s\033[0m\n", baseURL)
-		}
-	}
-
-	var body string
-	var err error
-	var reflectedInDOM bool
-
-	// 1. Check Normal Reflection
-	body, err = s.fetch(baseURL)
-	if err != nil {
-		if s.opts.Verbose {
-			fmt.Printf("Error fetching base URL: %v\n", err)
-		}
-		return
-	}
-
-	if !strings.Contains(body, "rix4uni") {
-		// 2. Check DOM Reflection
-		body, err = s.domScanner.GetDOM(baseURL)
-		if err != nil {
-			if s.opts.Verbose {
-				fmt.Printf("Error fetching DOM: %v\n", err)
-			}
-			return
-		}
-		if strings.Contains(body, "rix4uni") {
-			reflectedInDOM = true
-		}
-	}
-
-	if strings.Contains(body, "rix4uni") {
-		output.Reflected = true
-		s.printReflected(true)
-
-		if s.opts.SkipSpecialChar {
-			s.printJSON(output)
-			return
-		}
-
-		s.checkSpecialChars(inputURL, baseURL, reflectedInDOM, &output)
-		s.printJSON(output)
-
-	} else {
-		output.Reflected = false
-		s.printReflected(false)
-		s.printJSON(output)
-	}
-}
-
-func (s *Scanner) checkSpecialChars(inputURL, baseURL string, reflectedInDOM bool, output *JSONOutput) {
-	allowed := []string{}
-	blocked := []string{}
-	converted := []string{}
-
-	for _, char := range specialChars {
-		testURLs, err := utils.GenerateTargetURLs(inputURL, "rix4uni"+char)
-		if err != nil {
-			continue
-		}
-
-		// We only check the first generated URL for the char to avoid explosion
-		if len(testURLs) == 0 {
-			continue
-		}
-		testURL := testURLs[0]
-
-		if s.opts.Verbose && !s.opts.JSONOutput {
-			if s.opts.NoColor {
-				fmt.Printf("CHECKING: %s\n", testURL)
-			} else {
-				fmt.Printf("\033[95mCHECKING: %s\033[0m\n", testURL)
-			}
-		}
-
-		var testBody string
-		if reflectedInDOM {
-			testBody, err = s.domScanner.GetDOM(testURL)
-		} else {
-			testBody, err = s.fetch(testURL)
-		}
-
-		if err != nil {
-			continue
-		}
-
-		if strings.Contains(testBody, "rix4uni"+char) {
-			allowed = append(allowed, char)
-		} else if conv, exists := conversions[char]; exists && strings.Contains(testBody, "rix4uni"+conv) {
-			converted = append(converted, fmt.Sprintf("%s ➔ %s", char, conv))
-		} else {
-			blocked = append(blocked, char)
-		}
-	}
-
-	output.Allowed = allowed
-	output.Blocked = blocked
-	output.Converted = converted
-	output.Count = map[string]int{
-		"allowed":   len(allowed),
-		"blocked":   len(blocked),
-		"converted": len(converted),
-	}
-
-	if !s.opts.JSONOutput {
-		if s.opts.NoColor {
-			fmt.Printf("ALLOWED: %v\n", allowed)
-			fmt.Printf("BLOCKED: %v\n", blocked)
-			fmt.Printf("CONVERTED: %v\n", converted)
-		} else {
-			fmt.Printf("\033[32mALLOWED: %v\033[0m\n", allowed)
-			fmt.Printf("\033[31mBLOCKED: %v\033[0m\n", blocked)
-			fmt.Printf("\033[33mCONVERTED: %v\033[0m\n", converted)
-		}
-	}
-}
-
-func (s *Scanner) fetch(url string) (string, error) {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("User-Agent", s.opts.UserAgent)
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
-	}
-	return string(bodyBytes), nil
-}
-
-func (s *Scanner) printReflected(reflected bool) {
-	if s.opts.JSONOutput {
-		return
-	}
-	if reflected {
-		if s.opts.NoColor {
-			fmt.Println("REFLECTED: YES")
-		} else {
-			fmt.Println("\033[92mREFLECTED: YES\033[0m")
-		}
-	} else {
-		if s.opts.NoColor {
-			fmt.Println("REFLECTED: NO")
-		} else {
-			fmt.Println("\033[91mREFLECTED: NO\033[0m")
-		}
-	}
-}
-
-func (s *Scanner) printJSON(output JSONOutput) {
-	if !s.opts.JSONOutput {
-		return
-	}
-	// Initialize empty slices if nil to ensure JSON output is consistent [] instead of null
-	if output.Allowed == nil { output.Allowed = []string{} }
-	if output.Blocked == nil { output.Blocked = []string{} }
-	if output.Converted == nil { output.Converted = []string{} }
-	if output.Count == nil { output.Count = map[string]int{"allowed": 0, "blocked": 0, "converted": 0} }
-
-	jsonBytes, _ := json.MarshalIndent(output, "", "  ")
-	fmt.Println(string(jsonBytes))
-}
-
-// DOMScanner handles headless browser interactions
-type DOMScanner struct {
-	allocCtx    context.Context
-	allocCancel context.CancelFunc
-	ctx         context.Context
-	ctxCancel   context.CancelFunc
-}
-
-func NewDOMScanner(timeout int, proxy string, verifySSL bool) (*DOMScanner, error) {
-	opts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.Flag("headless", true),
-		chromedp.Flag("disable-gpu", true),
-		chromedp.Flag("no-sandbox", true),
-		chromedp.Flag("disable-dev-shm-usage", true),
-	)
-
-	if !verifySSL {
-		opts = append(opts, chromedp.Flag("ignore-certificate-errors", true))
-	}
-
-	if proxy != "" {
-		opts = append(opts, chromedp.ProxyServer(proxy))
-	}
-
-	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
-	ctx, ctxCancel := chromedp.NewContext(allocCtx)
-
-	return &DOMScanner{
-		allocCtx:    allocCtx,
-		allocCancel: allocCancel,
-		ctx:         ctx,
-		ctxCancel:   ctxCancel,
-	}, nil
-}
-
-func (s *DOMScanner) Close() {
-	s.ctxCancel()
-	s.allocCancel()
-}
-
-func (s *DOMScanner) GetDOM(url string) (string, error) {
-	var dom string
-	// Create a timeout context for the navigation
-	ctx, cancel := context.WithTimeout(s.ctx, 30*time.Second)
-	defer cancel()
-
-	err := chromedp.Run(ctx,
-		network.Enable(),
-		chromedp.Navigate(url),
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			// Simple wait for network idle or just a small delay
-			// Using a fixed delay for simplicity as network idle can be flaky
-			time.Sleep(2 * time.Second)
-			return nil
-		}),
-		chromedp.OuterHTML("html", &dom),
-	)
-	if err != nil {
-		return "", err
-	}
-	return dom, nil
-}
+package scanner
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// specialChars is the default probe set, replaced by Options.Chars.
+var specialChars = []string{`'`, `"`, `<`, `>`, `(`, `)`, "`", `{`, `}`, `/`, `\`, `;`}
+
+// extendedChars are added to the probe set by --extended-chars; attribute
+// and JavaScript contexts are often exploitable with these alone.
+var extendedChars = []string{`=`, `:`, `[`, `]`, `&`, `%`, " ", "\n"}
+
+// Options configures a Scanner. Zero values are valid but do not always
+// match the command's defaults: a zero Timeout never gives up on a request
+// (the command uses 15 seconds), redirects are only followed with
+// FollowRedirects set, up to MaxRedirects (the command follows up to 10),
+// and an empty UserAgent sends Go's. Concurrency is not used by Scanner;
+// callers choose how many scans to run at once.
+type Options struct {
+	UserAgent string
+	// Timeout bounds each HTTP request as a whole, in seconds, body
+	// included. The phase timeouts below abandon hung targets sooner;
+	// 0 leaves a phase bounded by Timeout alone.
+	Timeout               int
+	ConnectTimeout        time.Duration
+	TLSTimeout            time.Duration
+	ResponseHeaderTimeout time.Duration
+
+	SkipSpecialChar bool
+	NoColor         bool
+	Verbose         bool
+	JSONOutput      bool
+	Proxy           string
+	Concurrency     int
+	VerifySSL       bool
+	ArtifactsDir    string
+	Cookie          string
+	CookieFile      string
+	AuthBasic       string
+	AuthBearer      string
+	RetestConverted bool
+	InjectHeaders   []string
+	// ResetState re-sends the original request before every probe.
+	ResetState bool
+	// Chars replaces the special characters probed after a reflection.
+	Chars []string
+	// ExtendedChars adds extendedChars to the probe set.
+	ExtendedChars bool
+	// Params restricts injection to these parameter names; empty injects everywhere.
+	Params         []string
+	UniqueCanaries bool
+	LegacyCanary   bool
+	// Canary replaces the random reflection marker, e.g. to pass input validation.
+	Canary          string
+	InjectPath      bool
+	InjectCookies   bool
+	InjectNames     bool
+	InjectBase64    bool
+	Method          string
+	Data            string
+	EncodePayload   utils.EncodeMode
+	FollowRedirects bool
+	MaxRedirects    int
+	// ForbidOffscopeRedirects stops at redirects to a host other than the
+	// one the request was sent to.
+	ForbidOffscopeRedirects bool
+	// EncodePlaceholders encodes the payload put in place of {payload} in
+	// the URL for the component each placeholder is in, following
+	// EncodePayload, instead of inserting it verbatim.
+	EncodePlaceholders bool
+	// Filter is an expression over result fields selecting which results
+	// are printed and written; see ParseFilter.
+	Filter string
+	// PageCanary prefixes canaries with the most common CSS class prefix
+	// of each host's page so they blend into it.
+	PageCanary bool
+	// Matcher selects how reflections are detected; see ParseMatcher.
+	Matcher string
+	// Explain prints the reasoning behind every verdict (xssrecon explain).
+	Explain bool
+	// MaxBodySize caps how many bytes of each response are read; 0 reads all.
+	MaxBodySize int64
+	// MaxDOMSize caps how many bytes of a rendered page are copied out of
+	// the browser; larger pages only yield the nodes containing the canary.
+	// 0 serializes every page in full.
+	MaxDOMSize int64
+	// Record is a WARC file that receives all HTTP probe traffic.
+	Record string
+	// HostCache is a JSON file of per-host knowledge kept across runs.
+	HostCache string
+	// HostCacheTTL is how long the cache's finding that a host never needs
+	// the browser holds before its DOM checks are retried; 0 is forever.
+	HostCacheTTL time.Duration
+	// ForceDOM runs DOM checks even on hosts the cache says never need them.
+	ForceDOM bool
+	// Race starts the browser navigation for each base URL together with
+	// the HTTP request instead of after it.
+	Race bool
+	// DOMBudget caps how many targets may fall back to the browser; 0 is unlimited.
+	DOMBudget int
+	// SkipStatus lists status codes whose base responses are reported
+	// without running the character probes.
+	SkipStatus []int
+	// SkipErrorPages reports targets answering with a 4xx or 5xx status
+	// without probing them, like SkipStatus for every error status.
+	SkipErrorPages bool
+	// Resolve maps host names to IPs ("host:ip"); DNSServer replaces the
+	// system resolver for the HTTP client.
+	Resolve   []string
+	DNSServer string
+	// HTTPVersion forces "1.1" or "2"; empty keeps the transport default.
+	HTTPVersion string
+	// NoReuse opens a fresh connection for every request.
+	NoReuse bool
+	// DualProbe sends every character both raw and percent-encoded.
+	DualProbe bool
+	// EncodingVariants adds a double-encoded variant to the dual probe.
+	EncodingVariants bool
+	// UnicodeProbes sends confusable variants of every character to detect
+	// normalization after filtering.
+	UnicodeProbes bool
+	// CaseMutation probes common keywords in lower and mixed case.
+	CaseMutation bool
+	// ControlChars probes NUL, tab, CR, LF, CRLF and vertical tab handling.
+	ControlChars bool
+	// Batch classifies all special characters from a single request,
+	// falling back to one request per character for those it cannot.
+	Batch bool
+	// CombineParams checks every query parameter of a URL for reflection in
+	// a single request, each with its own marker, and only runs the full
+	// per-parameter checks for those that reflect.
+	CombineParams bool
+	// SkipKnownNegative skips the DOM check and probes of parameters the
+	// host cache saw not reflect in this many consecutive scans of the same
+	// page (0 disables).
+	SkipKnownNegative int
+	// KeepValue appends the canary and probes to each query parameter's
+	// original value instead of replacing it.
+	KeepValue bool
+	// WAFAdapt slows down hosts whose WAF blocks probes and retries the
+	// blocked characters raw and double-encoded.
+	WAFAdapt bool
+	// Multibyte probes multi-byte UTF-8, emoji and malformed sequences.
+	Multibyte bool
+	// CSTI probes {{7*7}}, ${7*7} and <%= 7*7 %> for template evaluation.
+	CSTI bool
+	// Blind is a callback server URL; every injection point is also sent a
+	// script-src payload loading <Blind>/<id> to catch stored XSS.
+	Blind string
+	// Mutations are user-defined transformations retried on every blocked
+	// or converted character.
+	Mutations []Mutation
+	// Payloads are complete XSS payloads fired after character recon.
+	Payloads []string
+	// Rewrite rules are applied to every input URL before it is scanned.
+	Rewrite []RewriteRule
+	// Polyglot fires well-known polyglot payloads after character recon.
+	Polyglot bool
+	// ConfirmExecution loads every suggested payload in the browser and
+	// reports those that open a dialog or log a computed marker to the
+	// console.
+	ConfirmExecution bool
+
+	// Rate limits in requests per second, shared by all workers; 0 disables.
+	RateLimit        float64
+	RateLimitPerHost float64
+	// Delay is waited before every request, plus up to Jitter at random.
+	Delay  time.Duration
+	Jitter time.Duration
+
+	// Output, when set, receives every result as JSON lines (gzip if it ends in .gz).
+	Output               string
+	OutputRotateSize     int64
+	OutputRotateInterval time.Duration
+	OutputSplit          string
+	// FaradayOutput and PlexTracOutput receive the findings as importable
+	// JSON reports when the scan ends.
+	FaradayOutput  string
+	PlexTracOutput string
+	// JUnitOutput receives every result as a JUnit XML report when the scan
+	// ends, failing the test cases of exploitable reflections.
+	JUnitOutput string
+	// TreeOutput receives the reflecting endpoints as a host/path tree,
+	// JSON or Graphviz (.dot).
+	TreeOutput string
+	// Lang selects the language of exported finding text (en, es, fr, de).
+	Lang string
+	// NotifyWebhook receives reflected findings, one request each or, with
+	// NotifyDigest, batched into one summary per interval.
+	NotifyWebhook string
+	NotifyDigest  time.Duration
+	// DefectDojo import target; findings are uploaded when the scan ends.
+	DefectDojoURL        string
+	DefectDojoKey        string
+	DefectDojoProduct    string
+	DefectDojoEngagement string
+	// HeaderCmd is a shell command printing "Name: value" header lines, or
+	// a bare token, to send with every request; its output is cached for
+	// HeaderCmdTTL and refreshed early when a response is 401 or 407.
+	HeaderCmd    string
+	HeaderCmdTTL time.Duration
+	// Manifest is where to record the scan configuration; defaults to
+	// <Output>.manifest.json when Output is set.
+	Manifest string
+	// Writer, if set, receives every result alongside the configured output
+	// files and is closed by Scanner.Close. It lets library users collect
+	// results without reading them back from disk.
+	Writer OutputWriter `json:"-"`
+}
+
+// JSONOutput is the result of checking one injection point, as written to
+// --output files and printed with --json.
+type JSONOutput struct {
+	// SchemaVersion is the SchemaVersion of the record format.
+	SchemaVersion string `json:"schema_version"`
+
+	Processing string         `json:"processing"`
+	BaseURL    string         `json:"baseurl"`
+	Param      string         `json:"param,omitempty"`
+	Canary     string         `json:"canary"`
+	Reflected  bool           `json:"reflected"`
+	Allowed    []string       `json:"allowed"`
+	Blocked    []string       `json:"blocked"`
+	Stripped   []string       `json:"stripped"`
+	Converted  []string       `json:"converted"`
+	Upgraded   []string       `json:"upgraded,omitempty"`
+	Count      map[string]int `json:"count"`
+	// Request is the rest of the request template Processing came from,
+	// which replay sends again.
+	Request *RecordedRequest `json:"request,omitempty"`
+	// Fingerprint identifies the injection point across scans; see Fingerprint.
+	Fingerprint string `json:"fingerprint"`
+	// ConvertedEncodings names the encoding seen for each converted
+	// character, e.g. html-named, html-hex-nosemi or js-unicode-escape.
+	ConvertedEncodings map[string]string `json:"converted_encodings,omitempty"`
+
+	AllowedRaw           []string `json:"allowed_raw,omitempty"`
+	AllowedEncoded       []string `json:"allowed_encoded,omitempty"`
+	AllowedDoubleEncoded []string `json:"allowed_double_encoded,omitempty"`
+	Normalized           []string `json:"normalized,omitempty"`
+	CaseMutation         []string `json:"case_mutation,omitempty"`
+	// ControlChars maps each probed control character to allowed,
+	// converted (form), stripped, split, truncated or blocked.
+	ControlChars map[string]string `json:"control_chars,omitempty"`
+	// Multibyte maps each multi-byte probe to intact, converted, replaced,
+	// decoded (char), mangled (bytes), stripped, truncated or blocked.
+	Multibyte map[string]string `json:"multibyte,omitempty"`
+	// CSTI lists the template expressions that came back evaluated.
+	CSTI []string `json:"csti,omitempty"`
+	// Probes details every character probe in the order it was sent.
+	Probes []Probe `json:"probes,omitempty"`
+	// Mutations lists, by blocked or converted character, the --mutations
+	// that got the raw character reflected.
+	Mutations map[string][]string `json:"mutations,omitempty"`
+	// PayloadsReflected lists the --payloads entries that reflected intact.
+	PayloadsReflected []string `json:"payloads_reflected,omitempty"`
+	// Contexts classifies where in the page each reflection of the canary
+	// lands (html, attribute-double, attribute-url, script-string-single,
+	// comment, style, ...), in document order.
+	Contexts []string `json:"contexts,omitempty"`
+	// ReflectionCount is how many times the canary was reflected and
+	// Occurrences gives the offset, context and surrounding text of each.
+	ReflectionCount int          `json:"reflection_count,omitempty"`
+	Occurrences     []Occurrence `json:"occurrences,omitempty"`
+	// Suggestions are payloads the observed character handling should let
+	// through in the reflection's context.
+	Suggestions []Suggestion `json:"suggestions,omitempty"`
+	// Executed lists, with --confirm-execution, the suggestions that ran
+	// in the browser.
+	Executed []Execution `json:"executed,omitempty"`
+	// Polyglots reports, by name, whether each polyglot survived unmodified.
+	Polyglots    map[string]bool `json:"polyglots,omitempty"`
+	MimeSniffing *MimeSniffHint  `json:"mime_sniffing,omitempty"`
+	// SetCookie lists the cookies whose Set-Cookie value reflects the canary.
+	SetCookie []string `json:"set_cookie_reflection,omitempty"`
+	// CSP lists the Content-Security-Policy and report-only policies of
+	// the response, with the weaknesses of each.
+	CSP []CSPPolicy `json:"csp,omitempty"`
+
+	// WAF names the WAF whose block page answered the base request or a
+	// probe; WAFBlocked lists the characters it blocked and WAFBypass, with
+	// --waf-adapt, the variant that got each of them through.
+	WAF        string            `json:"waf,omitempty"`
+	WAFBlocked []string          `json:"waf_blocked,omitempty"`
+	WAFBypass  map[string]string `json:"waf_bypass,omitempty"`
+
+	// BlindID is the path suffix of the --blind callback URL sent through
+	// this injection point.
+	BlindID string `json:"blind_id,omitempty"`
+
+	StatusCode    int      `json:"status_code,omitempty"`
+	ErrorPage     bool     `json:"error_page,omitempty"`
+	Skipped       string   `json:"skipped,omitempty"`
+	FinalURL      string   `json:"final_url,omitempty"`
+	RedirectChain []string `json:"redirect_chain,omitempty"`
+	// RedirectsTruncated is set when --max-redirects was reached; FinalURL
+	// is then the last redirect, which was analyzed instead of its target.
+	RedirectsTruncated bool `json:"redirects_truncated,omitempty"`
+}
+
+// Result is the result of checking one injection point.
+type Result = JSONOutput
+
+// Scanner checks URLs and request templates for reflections. Create one
+// with NewScanner and release it with Close.
+type Scanner struct {
+	opts       Options
+	client     *http.Client
+	domScanner *DOMScanner
+	artifacts  *ArtifactStore
+	cookies    []*http.Cookie
+	jar        http.CookieJar
+	writer     OutputWriter
+	limiter    *hostLimiter
+	hosts      *contactedHosts
+	wafPacer   *wafPacer
+	domBudget  *domBudget
+	hostCache  *HostCache
+	startedAt  time.Time
+	authHeader string
+	headerCmd  *headerCommand
+	canary     string
+	chars      []string
+	matcher    ReflectionMatcher
+	filter     *Filter
+	filterText bool
+	recorder   *WARCWriter
+
+	probeCounter   atomic.Uint64
+	canaryPrefixes sync.Map // host -> page class prefix, see --page-canary
+}
+
+// NewScanner validates opts and sets up the HTTP client, browser and
+// output files it asks for.
+func NewScanner(opts Options) (*Scanner, error) {
+	tr := &http.Transport{
+		TLSClientConfig:       &tls.Config{InsecureSkipVerify: !opts.VerifySSL},
+		TLSHandshakeTimeout:   opts.TLSTimeout,
+		ResponseHeaderTimeout: opts.ResponseHeaderTimeout,
+	}
+
+	dialer := newDialer(opts.DNSServer)
+	dialer.Timeout = time.Duration(opts.Timeout) * time.Second
+	if opts.ConnectTimeout > 0 {
+		dialer.Timeout = opts.ConnectTimeout
+		tr.DialContext = dialer.DialContext
+	}
+	if opts.DNSServer != "" {
+		tr.DialContext = dialer.DialContext
+	}
+
+	if opts.Proxy != "" {
+		if err := configureProxy(tr, opts.Proxy, dialer); err != nil {
+			return nil, err
+		}
+	}
+
+	overrides, err := parseResolve(opts.Resolve)
+	if err != nil {
+		return nil, err
+	}
+	if warning := resolveProxyWarning(opts.Proxy, len(overrides) > 0, opts.DNSServer != ""); warning != "" {
+		fmt.Fprintln(os.Stderr, warning)
+	}
+	if len(overrides) > 0 {
+		dial := tr.DialContext
+		if dial == nil {
+			dial = dialer.DialContext
+		}
+		tr.DialContext = overrideDial(dial, overrides)
+	}
+
+	switch opts.HTTPVersion {
+	case "1.1":
+		tr.Protocols = new(http.Protocols)
+		tr.Protocols.SetHTTP1(true)
+	case "2":
+		// Plain-text URLs use HTTP/2 with prior knowledge (h2c)
+		tr.Protocols = new(http.Protocols)
+		tr.Protocols.SetHTTP2(true)
+		tr.Protocols.SetUnencryptedHTTP2(true)
+	case "":
+	default:
+		return nil, fmt.Errorf("invalid HTTP version %q", opts.HTTPVersion)
+	}
+
+	// Reverse proxies that poison keep-alive connections hand back
+	// responses meant for another probe; one connection per request avoids it
+	tr.DisableKeepAlives = opts.NoReuse
+
+	limiter := newHostLimiter(opts.RateLimit, opts.RateLimitPerHost, opts.Delay, opts.Jitter)
+	hosts := newContactedHosts()
+
+	var jar http.CookieJar
+	if opts.CookieFile != "" {
+		jar, _ = cookiejar.New(nil)
+		if err := loadNetscapeCookies(opts.CookieFile, jar); err != nil {
+			return nil, err
+		}
+	}
+
+	client := &http.Client{
+		Transport: tr,
+		Timeout:   time.Duration(opts.Timeout) * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !opts.FollowRedirects {
+				return http.ErrUseLastResponse
+			}
+			if len(via) > opts.MaxRedirects {
+				return http.ErrUseLastResponse
+			}
+			if opts.ForbidOffscopeRedirects && offScope(req.URL, via[0].URL) {
+				hosts.Refuse(req.URL)
+				return http.ErrUseLastResponse
+			}
+			if jar != nil {
+				addJarCookies(req, jar)
+			}
+			limiter.Wait(req.URL.String())
+			hosts.Add(req.URL)
+			return nil
+		},
+	}
+	if jar != nil {
+		client.Jar = storingJar{jar}
+	}
+
+	authHeader, err := authorizationHeader(opts)
+	if err != nil {
+		return nil, err
+	}
+	headerCmd, err := newHeaderCommand(opts.HeaderCmd, opts.HeaderCmdTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	var cookies []*http.Cookie
+	if opts.Cookie != "" {
+		cookies, err = parseCookieHeader(opts.Cookie)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	domScanner, err := NewDOMScanner(opts, jar, cookies)
+	if err != nil {
+		return nil, err
+	}
+	domScanner.headerCmd = headerCmd
+	domScanner.hosts = hosts
+	domScanner.forbidOffscope = opts.ForbidOffscopeRedirects
+
+	var artifacts *ArtifactStore
+	if opts.ArtifactsDir != "" {
+		artifacts, err = NewArtifactStore(opts.ArtifactsDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := reportLanguage(opts.Lang); err != nil {
+		return nil, err
+	}
+
+	var writers MultiWriter
+	if opts.Output != "" {
+		var fw OutputWriter
+		if opts.OutputSplit != "" {
+			fw, err = NewSplitWriter(opts.Output, opts.OutputSplit, opts.OutputRotateSize, opts.OutputRotateInterval)
+		} else {
+			fw, err = NewFileWriter(opts.Output, opts.OutputRotateSize, opts.OutputRotateInterval)
+		}
+		if err != nil {
+			return nil, err
+		}
+		writers = append(writers, fw)
+	}
+	if opts.DefectDojoURL != "" {
+		dojo, err := NewDefectDojoExporter(opts.DefectDojoURL, opts.DefectDojoKey, opts.DefectDojoProduct, opts.DefectDojoEngagement, opts.Lang)
+		if err != nil {
+			return nil, err
+		}
+		writers = append(writers, dojo)
+	}
+	if opts.NotifyWebhook != "" {
+		notifier, err := NewWebhookNotifier(opts.NotifyWebhook, opts.NotifyDigest, opts.Lang)
+		if err != nil {
+			return nil, err
+		}
+		writers = append(writers, notifier)
+	}
+	if opts.JUnitOutput != "" {
+		junit, err := NewJUnitWriter(opts.JUnitOutput, opts.Lang)
+		if err != nil {
+			return nil, err
+		}
+		writers = append(writers, junit)
+	}
+	if opts.FaradayOutput != "" {
+		faraday, err := NewFaradayWriter(opts.FaradayOutput, opts.Lang)
+		if err != nil {
+			return nil, err
+		}
+		writers = append(writers, faraday)
+	}
+	if opts.PlexTracOutput != "" {
+		plexTrac, err := NewPlexTracWriter(opts.PlexTracOutput, opts.Lang)
+		if err != nil {
+			return nil, err
+		}
+		writers = append(writers, plexTrac)
+	}
+	if opts.TreeOutput != "" {
+		writers = append(writers, NewTreeWriter(opts.TreeOutput))
+	}
+	if opts.Writer != nil {
+		writers = append(writers, opts.Writer)
+	}
+	var writer OutputWriter
+	switch len(writers) {
+	case 0:
+	case 1:
+		writer = writers[0]
+	default:
+		writer = writers
+	}
+
+	s := &Scanner{
+		opts:       opts,
+		client:     client,
+		domScanner: domScanner,
+		artifacts:  artifacts,
+		cookies:    cookies,
+		jar:        jar,
+		writer:     writer,
+		limiter:    limiter,
+		hosts:      hosts,
+		wafPacer:   newWAFPacer(opts.WAFAdapt),
+		domBudget:  newDOMBudget(opts.DOMBudget),
+		startedAt:  time.Now().UTC(),
+		authHeader: authHeader,
+		headerCmd:  headerCmd,
+		canary:     randomCanary(),
+		chars:      specialChars,
+	}
+	s.matcher, err = ParseMatcher(opts.Matcher)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Filter != "" {
+		if s.filter, err = ParseFilter(opts.Filter); err != nil {
+			return nil, err
+		}
+		// Text output is held back as for --json until the filter has
+		// seen the finished result, then printed by printFiltered
+		if !opts.JSONOutput {
+			s.filterText = true
+			s.opts.JSONOutput = true
+		}
+	}
+	if len(opts.Chars) > 0 {
+		s.chars = opts.Chars
+	}
+	if opts.ExtendedChars {
+		s.chars = slices.Clone(s.chars)
+		for _, c := range extendedChars {
+			if !slices.Contains(s.chars, c) {
+				s.chars = append(s.chars, c)
+			}
+		}
+	}
+	if opts.LegacyCanary {
+		s.canary = legacyCanary
+	}
+	if opts.Blind != "" {
+		if err := validateBlind(opts.Blind); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Canary != "" {
+		if err := validateCanary(opts.Canary, s.chars); err != nil {
+			return nil, err
+		}
+		s.canary = opts.Canary
+	}
+	if opts.Record != "" {
+		s.recorder, err = NewWARCWriter(opts.Record)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.HostCache != "" {
+		s.hostCache, err = LoadHostCache(opts.HostCache, opts.HostCacheTTL, opts.ForceDOM)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := s.writeManifest(false); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close shuts down the browser, flushes and closes every output, saves the
+// host cache and prints the contacted hosts.
+func (s *Scanner) Close() {
+	if s.domScanner != nil {
+		s.domScanner.Close()
+	}
+	if s.writer != nil {
+		if err := s.writer.Close(); err != nil {
+			fmt.Printf("Error closing output: %v\n", err)
+		}
+	}
+	if err := s.recorder.Close(); err != nil {
+		fmt.Printf("Error closing record file: %v\n", err)
+	}
+	if err := s.hostCache.Save(); err != nil {
+		fmt.Printf("Error saving host cache: %v\n", err)
+	}
+	if err := s.writeManifest(true); err != nil {
+		fmt.Printf("Error writing manifest: %v\n", err)
+	}
+	s.printContactedHosts()
+}
+
+// Scan checks every injection point of inputURL: its query parameters or
+// {payload} placeholders, plus the extra points enabled in Options.
+func (s *Scanner) Scan(inputURL string) {
+	s.ScanRequest(&utils.Request{URL: inputURL})
+}
+
+// ScanRequest scans a request template, such as one loaded from a raw
+// request file. Fields left empty fall back to the scanner options.
+func (s *Scanner) ScanRequest(req *utils.Request) {
+	req = s.rewriteRequest(req)
+	if !s.opts.JSONOutput {
+		if s.opts.NoColor {
+			fmt.Printf("\nPROCESSING: %s\n", req.URL)
+		} else {
+			fmt.Printf("\n\033[96mPROCESSING: %s\033[0m\n", req.URL)
+		}
+	}
+
+	s.learnCanaryPrefix(req)
+	targets, err := s.generateTargets(req, s.canaryPrefix(req.URL)+s.canary)
+	if err != nil {
+		if s.opts.Verbose {
+			fmt.Printf("Error generating target URLs: %v\n", err)
+		}
+		return
+	}
+
+	var selected []utils.Target
+	for _, target := range targets {
+		if len(s.opts.Params) > 0 && !utils.MatchParam(target.Param, s.opts.Params) {
+			continue
+		}
+		selected = append(selected, target)
+	}
+
+	// Query parameters are first checked together in one request
+	if s.opts.CombineParams {
+		query, rest := combinedQueryTargets(selected)
+		selected = append(s.combinedProbe(req, query), rest...)
+	}
+
+	for _, target := range selected {
+		s.processBaseURL(req, target)
+	}
+}
+
+// generateTargets builds every injection target for req: query parameters
+// or {payload} placeholders, body fields, plus path segments, request
+// headers and cookies when enabled.
+func (s *Scanner) generateTargets(req *utils.Request, payload string) ([]utils.Target, error) {
+	return s.generateTargetsEncoded(req, payload, s.opts.EncodePayload)
+}
+
+// generateTargetsEncoded is generateTargets with an explicit query encoding mode.
+func (s *Scanner) generateTargetsEncoded(req *utils.Request, payload string, encodeMode utils.EncodeMode) ([]utils.Target, error) {
+	inputURL := req.URL
+	method := s.opts.Method
+	if req.Method != "" {
+		method = req.Method
+	}
+	data := s.opts.Data
+	if req.Body != "" {
+		data = req.Body
+	}
+	injectHeaders := append(append([]string{}, s.opts.InjectHeaders...), req.InjectHeaders...)
+	reqCookies := requestCookies(req)
+
+	hasExtra := len(injectHeaders) > 0 || s.opts.InjectPath || s.opts.InjectNames || s.opts.InjectBase64 || s.opts.InjectCookies || data != ""
+	if encodeMode == "" {
+		encodeMode = utils.EncodeAlways
+	}
+	generate := utils.GenerateTargetsWithEncoding
+	if s.opts.KeepValue {
+		generate = utils.GenerateTargetsKeepingValue
+	}
+	targets, err := generate(inputURL, payload, encodeMode)
+	if err != nil && !(errors.Is(err, utils.ErrNoInjectionPoints) && hasExtra) {
+		return nil, err
+	}
+	if s.opts.EncodePlaceholders && strings.Contains(inputURL, "{payload}") {
+		targets[0].URL = utils.FillPlaceholders(inputURL, payload, encodeMode)
+	}
+	if s.opts.InjectPath && !strings.Contains(inputURL, "{payload}") {
+		pathTargets, err := utils.GeneratePathTargets(inputURL, payload)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, pathTargets...)
+	}
+	if s.opts.InjectNames && !strings.Contains(inputURL, "{payload}") {
+		nameTargets, err := utils.GenerateParamNameTargets(inputURL, payload)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, nameTargets...)
+	}
+	if s.opts.InjectBase64 && !strings.Contains(inputURL, "{payload}") {
+		b64Targets, err := utils.GenerateBase64Targets(inputURL, payload)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, b64Targets...)
+	}
+	targets = append(targets, utils.GenerateHeaderTargets(inputURL, injectHeaders, payload)...)
+	if s.opts.InjectCookies {
+		var names []string
+		for _, c := range cookiesFor(s.jar, s.cookies, inputURL, reqCookies) {
+			names = append(names, c.Name)
+		}
+		targets = append(targets, utils.GenerateCookieTargets(inputURL, names, payload)...)
+	}
+
+	// Targets outside the body still send the body template unmodified
+	defaultBody := strings.ReplaceAll(data, "{payload}", "")
+	for i := range targets {
+		targets[i].Body = defaultBody
+	}
+	if data != "" {
+		targets = append(targets, utils.GenerateBodyTargets(inputURL, data, payload)...)
+	}
+
+	for i := range targets {
+		targets[i].Method = method
+		targets[i].Headers = mergeMaps(req.Headers, targets[i].Headers)
+		targets[i].Cookies = mergeMaps(reqCookies, targets[i].Cookies)
+		targets[i].Input = inputURL
+		targets[i].Payload = payload
+	}
+
+	if len(targets) == 0 {
+		return nil, utils.ErrNoInjectionPoints
+	}
+	return targets, nil
+}
+
+// mergeMaps returns base with overrides applied, or nil if both are empty.
+func mergeMaps(base, overrides map[string]string) map[string]string {
+	if len(base) == 0 && len(overrides) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(base)+len(overrides))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
+
+// newOutput starts the record of one injection point of req, probed at
+// baseURL with canary.
+func (s *Scanner) newOutput(req *utils.Request, baseURL, param, canary string) JSONOutput {
+	return JSONOutput{
+		Processing: req.URL,
+		Request:    s.recordRequest(req),
+		BaseURL:    baseURL,
+		Param:      param,
+		Canary:     canary,
+	}
+}
+
+func (s *Scanner) processBaseURL(req *utils.Request, target utils.Target) {
+	canary := s.newCanary(target.URL, target.Param)
+	if canary != s.canaryPrefix(target.URL)+s.canary {
+		var ok bool
+		target, ok = s.probeTarget(req, target.Param, canary)
+		if !ok {
+			return
+		}
+	}
+
+	baseURL := target.URL
+	output := s.newOutput(req, baseURL, target.Param, canary)
+
+	if !s.opts.JSONOutput {
+		label := baseURL
+		if target.URL == req.URL || strings.HasPrefix(target.Param, utils.BodyParamPrefix) {
+			label = fmt.Sprintf("%s [%s]", baseURL, target.Param)
+		}
+		s.printBaseURL(label)
+	}
+
+	var body string
+	var err error
+	var reflectedInDOM bool
+
+	// In race mode, or on hosts known to need the browser, it starts
+	// alongside the HTTP request
+	var raceDOM <-chan domResult
+	if (s.opts.Race || s.hostCache.NeedsDOM(target.URL)) && isGet(target) && s.domBudget.Take(target.Param) {
+		raceDOM = s.startDOM(target)
+	}
+
+	// 1. Check Normal Reflection
+	resp, err := s.fetchResponse(target)
+	if err != nil {
+		if s.opts.Verbose {
+			fmt.Printf("Error fetching base URL: %v\n", err)
+		}
+		return
+	}
+	body = s.reflectionBody(resp.Body, resp.Decoded, canary)
+	if len(resp.Redirects) > 0 || resp.Truncated {
+		output.FinalURL = resp.FinalURL
+		output.RedirectChain = resp.Redirects
+		output.RedirectsTruncated = resp.Truncated
+		s.printRedirects(resp)
+	}
+	if names := setCookieReflections(resp, canary); len(names) > 0 {
+		output.SetCookie = names
+		s.printSetCookie(names)
+	}
+	output.StatusCode = resp.StatusCode
+	output.CSP = parseCSP(resp)
+	if output.WAF = detectWAF(resp); output.WAF != "" {
+		s.wafPacer.Blocked(target.URL)
+		s.printWAF(output.WAF, nil)
+	}
+
+	// Dead endpoints are reported but not probed any further
+	skipped := slices.Contains(s.opts.SkipStatus, resp.StatusCode)
+	if skipped {
+		output.Skipped = fmt.Sprintf("status %d", resp.StatusCode)
+		s.printSkipped(output.Skipped)
+	}
+
+	// Error templates often echo the request, but also reflect junk that
+	// never reaches a real page
+	output.ErrorPage = resp.StatusCode >= 400
+	if output.ErrorPage && s.opts.SkipErrorPages && !skipped {
+		skipped = true
+		output.Skipped = fmt.Sprintf("error page (status %d)", resp.StatusCode)
+		s.printSkipped(output.Skipped)
+	}
+
+	// Parameters that never reflected on this unchanged page in the last
+	// scans are not checked any further
+	hash := pageHash(resp)
+	if n := s.opts.SkipKnownNegative; n > 0 && !skipped && !s.reflects(body, canary) && s.hostCache.KnownNegative(req.URL, target.Param, hash, n) {
+		skipped = true
+		output.Skipped = fmt.Sprintf("known negative in the last %d scans", n)
+		s.printSkipped(output.Skipped)
+	}
+
+	s.explainReflection("HTTP", body, canary)
+
+	// The headless browser can only navigate with GET
+	useDOM := !s.reflects(body, canary) && isGet(target) && !skipped
+	if reason := domPointless(target.URL, resp); useDOM && raceDOM == nil && reason != "" {
+		useDOM = false
+		if s.opts.Verbose && !s.opts.JSONOutput {
+			fmt.Printf("DOM CHECK SKIPPED: %s\n", reason)
+		}
+	}
+	if reason, skip := s.hostCache.SkipDOM(target.URL); useDOM && raceDOM == nil && skip {
+		useDOM = false
+		if s.opts.Verbose && !s.opts.JSONOutput {
+			fmt.Printf("DOM CHECK SKIPPED: %s\n", reason)
+		}
+	}
+	if useDOM && raceDOM == nil && !s.domBudget.Take(target.Param) {
+		useDOM = false
+		output.Skipped = "dom budget exhausted"
+		s.printSkipped(output.Skipped)
+	}
+	if useDOM {
+		// 2. Check DOM Reflection
+		if raceDOM != nil {
+			r := <-raceDOM
+			body, err = r.body, r.err
+		} else {
+			body, err = s.getDOM(target)
+		}
+		if err != nil {
+			if s.opts.Verbose {
+				fmt.Printf("Error fetching DOM: %v\n", err)
+			}
+			return
+		}
+		if s.reflects(body, canary) {
+			reflectedInDOM = true
+		}
+		s.explainReflection("DOM", body, canary)
+	}
+
+	reflectionContext := "http"
+	if reflectedInDOM {
+		reflectionContext = "dom"
+	}
+	output.Fingerprint = Fingerprint(req.URL, target.Param, reflectionContext)
+	s.hostCache.RecordReflection(target.URL, useDOM, s.reflects(body, canary), reflectedInDOM)
+
+	// Stored input may render somewhere else, whether or not it reflects here
+	if s.opts.Blind != "" && !skipped {
+		id, err := s.blindProbe(req, target)
+		if err != nil && s.opts.Verbose {
+			fmt.Printf("Error sending blind payload: %v\n", err)
+		}
+		output.BlindID = id
+		s.printBlind(id)
+	}
+	if !skipped {
+		s.hostCache.RecordParam(req.URL, target.Param, hash, s.reflects(body, canary))
+	}
+
+	if s.reflects(body, canary) {
+		output.Reflected = true
+		s.printReflected(true)
+		if output.ErrorPage {
+			s.printErrorPage(resp.StatusCode)
+		}
+		if !reflectedInDOM {
+			output.MimeSniffing = mimeSniffHint(resp, s.matcher, canary)
+			s.printMimeSniffing(output.MimeSniffing)
+		}
+		s.printCSP(output.CSP)
+		s.saveEvidence(target, resp, body, reflectedInDOM)
+		output.Contexts = s.reflectionContexts(body, canary)
+		s.printContexts(output.Contexts)
+		output.ReflectionCount = len(s.matcher.Match(body, canary))
+		output.Occurrences = s.occurrences(body, canary)
+		s.printOccurrences(output.Occurrences)
+
+		if s.opts.SkipSpecialChar || skipped {
+			s.printJSON(output)
+			return
+		}
+
+		s.checkSpecialChars(req, target, reflectedInDOM, &output)
+		output.Suggestions = s.suggestPayloads(output.Contexts, output.Allowed)
+		s.printSuggestions(output.Suggestions)
+		if s.opts.ConfirmExecution {
+			output.Executed = s.executionProbe(req, target, output.Suggestions)
+			s.printExecuted(output.Executed)
+		}
+		s.printJSON(output)
+
+	} else {
+		output.Reflected = false
+		s.printReflected(false)
+		s.printJSON(output)
+	}
+}
+
+func (s *Scanner) checkSpecialChars(req *utils.Request, target utils.Target, reflectedInDOM bool, output *JSONOutput) {
+	allowed := []string{}
+	blocked := []string{}
+	stripped := []string{}
+	converted := []string{}
+	var convertedProbes []convertedProbe
+
+	batch := s.batchProbe(req, target, reflectedInDOM)
+	for _, char := range s.chars {
+		var canary, testBody string
+		var testTarget utils.Target
+		var probe Probe
+		if marker, ok := batch.marker(char); ok {
+			// Classified from the batch response, against this character's marker
+			canary, testTarget, testBody = marker, batch.target, batch.body
+			probe = batch.probe
+			probe.Char = char
+		} else {
+			s.resetState(req)
+			// Probe the same injection point that reflected the base canary
+			canary = s.newCanary(target.URL, target.Param)
+			testTarget, ok = s.probeTarget(req, target.Param, canary+char)
+			if !ok {
+				continue
+			}
+			testURL := testTarget.URL
+
+			if s.opts.Verbose && !s.opts.JSONOutput {
+				if s.opts.NoColor {
+					fmt.Printf("CHECKING: %s\n", testURL)
+				} else {
+					fmt.Printf("\033[95mCHECKING: %s\033[0m\n", testURL)
+				}
+			}
+
+			var err error
+			testBody, probe, err = s.sendProbe(testTarget, char, reflectedInDOM)
+			if err != nil {
+				output.Probes = append(output.Probes, probe)
+				continue
+			}
+		}
+
+		verdict, form := s.classifyChar(testBody, canary, char)
+		probe.Classification = verdict
+		probe.Encoding = form.encoding
+		switch verdict {
+		case "allowed":
+			probe.EvidenceOffset = s.suffixOffset(testBody, canary, char)
+		case "converted":
+			probe.EvidenceOffset = s.suffixOffset(testBody, canary, form.text)
+		}
+		output.Probes = append(output.Probes, probe)
+
+		switch verdict {
+		case "allowed":
+			allowed = append(allowed, char)
+			s.explainChar("allowed", char, canary, canary+char, testBody)
+		case "converted":
+			conv := form.text
+			s.explainChar("converted", char, canary, canary+conv, testBody)
+			converted = append(converted, fmt.Sprintf("%s ➔ %s", char, conv))
+			s.hostCache.RecordEncoder(target.URL, form.encoding)
+			if output.ConvertedEncodings == nil {
+				output.ConvertedEncodings = make(map[string]string)
+			}
+			output.ConvertedEncodings[char] = form.encoding
+			convertedProbes = append(convertedProbes, convertedProbe{char: char, conv: conv, canary: canary, target: testTarget, body: testBody})
+		case "stripped":
+			stripped = append(stripped, char)
+			s.explainChar("stripped", char, canary, canary, testBody)
+		default:
+			blocked = append(blocked, char)
+			s.explainChar("blocked", char, canary, canary, testBody)
+			if probe.WAF != "" {
+				output.WAF = probe.WAF
+				output.WAFBlocked = append(output.WAFBlocked, char)
+			}
+		}
+	}
+
+	// Characters a WAF blocked may pass in another encoding
+	if s.opts.WAFAdapt && len(output.WAFBlocked) > 0 {
+		output.WAFBypass = s.wafBypass(req, target, reflectedInDOM, output.WAFBlocked)
+		for char := range output.WAFBypass {
+			blocked = slices.DeleteFunc(blocked, func(c string) bool { return c == char })
+			allowed = append(allowed, char)
+		}
+	}
+
+	// Converted characters may only be encoded in some contexts; re-test
+	// them and upgrade any that also come back raw.
+	var upgraded []string
+	if s.opts.RetestConverted && len(convertedProbes) > 0 {
+		var remaining []convertedProbe
+		upgraded, remaining = s.retestConverted(convertedProbes, reflectedInDOM)
+		if len(upgraded) > 0 {
+			converted = []string{}
+			for _, p := range remaining {
+				converted = append(converted, fmt.Sprintf("%s ➔ %s", p.char, p.conv))
+			}
+			for _, p := range convertedProbes {
+				if !containsProbe(remaining, p.char) {
+					allowed = append(allowed, p.char)
+					delete(output.ConvertedEncodings, p.char)
+				}
+			}
+		}
+	}
+
+	output.Allowed = allowed
+	output.Blocked = blocked
+	output.Stripped = stripped
+	output.Converted = converted
+	output.Upgraded = upgraded
+	if s.opts.DualProbe || s.opts.EncodingVariants {
+		output.AllowedRaw, output.AllowedEncoded, output.AllowedDoubleEncoded = s.dualProbe(req, target, reflectedInDOM)
+	}
+	if s.opts.UnicodeProbes {
+		output.Normalized = s.unicodeProbe(req, target, reflectedInDOM)
+	}
+	if s.opts.CaseMutation {
+		output.CaseMutation = s.caseMutationProbe(req, target, reflectedInDOM)
+	}
+	if s.opts.ControlChars {
+		output.ControlChars = s.controlProbe(req, target, reflectedInDOM)
+	}
+	if s.opts.Multibyte {
+		output.Multibyte = s.multibyteProbe(req, target, reflectedInDOM)
+	}
+	if s.opts.CSTI {
+		output.CSTI = s.cstiProbe(req, target, reflectedInDOM)
+	}
+	if len(s.opts.Mutations) > 0 {
+		output.Mutations = s.mutationProbe(req, target, reflectedInDOM, allowed)
+	}
+	if len(s.opts.Payloads) > 0 {
+		output.PayloadsReflected = s.payloadProbe(req, target, reflectedInDOM, s.opts.Payloads)
+	}
+	if s.opts.Polyglot {
+		output.Polyglots = s.polyglotProbe(req, target, reflectedInDOM)
+	}
+	output.Count = map[string]int{
+		"allowed":   len(allowed),
+		"blocked":   len(blocked),
+		"stripped":  len(stripped),
+		"converted": len(converted),
+	}
+
+	s.printCharResults(output)
+	s.printWAF(output.WAF, output.WAFBlocked)
+	if len(output.WAFBypass) > 0 && !s.opts.JSONOutput {
+		fmt.Printf("WAF BYPASS: %v\n", output.WAFBypass)
+	}
+}
+
+// printCharResults prints the character probe results of output.
+func (s *Scanner) printCharResults(output *JSONOutput) {
+	if s.opts.JSONOutput {
+		return
+	}
+	if s.opts.NoColor {
+		fmt.Printf("ALLOWED: %v\n", printableChars(output.Allowed))
+		fmt.Printf("BLOCKED: %v\n", printableChars(output.Blocked))
+		if len(output.Stripped) > 0 {
+			fmt.Printf("STRIPPED: %v\n", printableChars(output.Stripped))
+		}
+		fmt.Printf("CONVERTED: %v\n", printableChars(output.Converted))
+		if len(output.Upgraded) > 0 {
+			fmt.Printf("UPGRADED: %v\n", printableChars(output.Upgraded))
+		}
+		if s.opts.DualProbe || s.opts.EncodingVariants {
+			fmt.Printf("ALLOWED (RAW): %v\n", printableChars(output.AllowedRaw))
+			fmt.Printf("ALLOWED (ENCODED): %v\n", printableChars(output.AllowedEncoded))
+		}
+		if s.opts.EncodingVariants {
+			fmt.Printf("ALLOWED (DOUBLE-ENCODED): %v\n", printableChars(output.AllowedDoubleEncoded))
+		}
+		if len(output.Normalized) > 0 {
+			fmt.Printf("NORMALIZED: %v\n", output.Normalized)
+		}
+		if len(output.CaseMutation) > 0 {
+			fmt.Printf("ALLOWED WITH CASE MUTATION: %v\n", output.CaseMutation)
+		}
+		if len(output.ControlChars) > 0 {
+			fmt.Printf("CONTROL CHARS: %v\n", output.ControlChars)
+		}
+		if len(output.Multibyte) > 0 {
+			fmt.Printf("MULTIBYTE: %v\n", output.Multibyte)
+		}
+		if len(output.CSTI) > 0 {
+			fmt.Printf("TEMPLATE INJECTION: %v\n", output.CSTI)
+		}
+		for _, line := range mutationLines(output.Mutations) {
+			fmt.Printf("MUTATION: %s\n", line)
+		}
+		if len(s.opts.Payloads) > 0 {
+			fmt.Printf("PAYLOADS REFLECTED: %d/%d\n", len(output.PayloadsReflected), len(s.opts.Payloads))
+			for _, p := range output.PayloadsReflected {
+				fmt.Printf("PAYLOAD: %s\n", p)
+			}
+		}
+		if len(output.Polyglots) > 0 {
+			fmt.Printf("POLYGLOTS SURVIVED: %v\n", survivedPolyglots(output.Polyglots))
+		}
+	} else {
+		fmt.Printf("\033[32mALLOWED: %v\033[0m\n", printableChars(output.Allowed))
+		fmt.Printf("\033[31mBLOCKED: %v\033[0m\n", printableChars(output.Blocked))
+		if len(output.Stripped) > 0 {
+			fmt.Printf("\033[91mSTRIPPED: %v\033[0m\n", printableChars(output.Stripped))
+		}
+		fmt.Printf("\033[33mCONVERTED: %v\033[0m\n", printableChars(output.Converted))
+		if len(output.Upgraded) > 0 {
+			fmt.Printf("\033[92mUPGRADED: %v\033[0m\n", printableChars(output.Upgraded))
+		}
+		if s.opts.DualProbe || s.opts.EncodingVariants {
+			fmt.Printf("\033[32mALLOWED (RAW): %v\033[0m\n", printableChars(output.AllowedRaw))
+			fmt.Printf("\033[32mALLOWED (ENCODED): %v\033[0m\n", printableChars(output.AllowedEncoded))
+		}
+		if s.opts.EncodingVariants {
+			fmt.Printf("\033[32mALLOWED (DOUBLE-ENCODED): %v\033[0m\n", printableChars(output.AllowedDoubleEncoded))
+		}
+		if len(output.Normalized) > 0 {
+			fmt.Printf("\033[92mNORMALIZED: %v\033[0m\n", output.Normalized)
+		}
+		if len(output.CaseMutation) > 0 {
+			fmt.Printf("\033[92mALLOWED WITH CASE MUTATION: %v\033[0m\n", output.CaseMutation)
+		}
+		if len(output.ControlChars) > 0 {
+			fmt.Printf("\033[36mCONTROL CHARS: %v\033[0m\n", output.ControlChars)
+		}
+		if len(output.Multibyte) > 0 {
+			fmt.Printf("\033[36mMULTIBYTE: %v\033[0m\n", output.Multibyte)
+		}
+		if len(output.CSTI) > 0 {
+			fmt.Printf("\033[92mTEMPLATE INJECTION: %v\033[0m\n", output.CSTI)
+		}
+		for _, line := range mutationLines(output.Mutations) {
+			fmt.Printf("\033[92mMUTATION: %s\033[0m\n", line)
+		}
+		if len(s.opts.Payloads) > 0 {
+			fmt.Printf("\033[92mPAYLOADS REFLECTED: %d/%d\033[0m\n", len(output.PayloadsReflected), len(s.opts.Payloads))
+			for _, p := range output.PayloadsReflected {
+				fmt.Printf("\033[92mPAYLOAD: %s\033[0m\n", p)
+			}
+		}
+		if len(output.Polyglots) > 0 {
+			fmt.Printf("\033[92mPOLYGLOTS SURVIVED: %v\033[0m\n", survivedPolyglots(output.Polyglots))
+		}
+	}
+}
+
+// response is the part of an HTTP response the scanner inspects.
+type response struct {
+	Body string
+	// Decoded holds the string values of a JSON response, decoded, for
+	// targets with DecodeJSON set.
+	Decoded    string
+	StatusCode int
+	Header     http.Header
+	FinalURL   string
+	// Redirects lists every URL that redirected, in order, before FinalURL.
+	Redirects []string
+	// Truncated is set when FinalURL redirects too, but --max-redirects
+	// stopped the chain there.
+	Truncated bool
+	Timings   phaseTimings
+	// Request is the last request sent, the one FinalURL answered.
+	Request *http.Request
+}
+
+func (s *Scanner) fetch(target utils.Target) (string, error) {
+	resp, err := s.fetchResponse(target)
+	if err != nil {
+		return "", err
+	}
+	return resp.Body, nil
+}
+
+func (s *Scanner) fetchResponse(target utils.Target) (*response, error) {
+	s.explainRequest("HTTP", target)
+	method := target.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	var reqBody io.Reader
+	if target.Body != "" {
+		reqBody = strings.NewReader(target.Body)
+	}
+	req, err := http.NewRequest(method, target.URL, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", s.opts.UserAgent)
+	if target.Body != "" {
+		req.Header.Set("Content-Type", utils.BodyContentType(target.Body))
+	}
+	if cookies := cookiesFor(s.jar, s.cookies, target.URL, target.Cookies); len(cookies) > 0 {
+		req.Header.Set("Cookie", cookieHeader(cookies))
+	}
+	if s.authHeader != "" {
+		req.Header.Set("Authorization", s.authHeader)
+	}
+	cmdHeaders, err := s.headerCmd.Headers()
+	if err != nil && s.opts.Verbose {
+		fmt.Printf("Error refreshing headers: %v\n", err)
+	}
+	for k, v := range cmdHeaders {
+		req.Header.Set(k, v)
+	}
+	for k, v := range target.Headers {
+		req.Header.Set(k, v)
+	}
+
+	s.limiter.Wait(target.URL)
+	s.wafPacer.Wait(target.URL)
+	s.hosts.Add(req.URL)
+	req, trace := traceRequest(req)
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	latency := time.Since(start)
+	s.headerCmd.Rejected(resp.StatusCode)
+	defer resp.Body.Close()
+
+	var bodyReader io.Reader = resp.Body
+	if s.opts.MaxBodySize > 0 {
+		bodyReader = io.LimitReader(resp.Body, s.opts.MaxBodySize)
+	}
+	bodyBytes, err := io.ReadAll(bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.recorder.Record(req, target, resp, bodyBytes); err != nil && s.opts.Verbose {
+		fmt.Printf("Error recording traffic: %v\n", err)
+	}
+
+	body := string(bodyBytes)
+	var decoded string
+	if target.DecodeJSON {
+		decoded, _ = utils.JSONStrings(body)
+	}
+
+	// Each redirected request links back to the response that caused it
+	var redirects []string
+	for r := resp.Request; r.Response != nil; r = r.Response.Request {
+		redirects = append([]string{r.Response.Request.URL.String()}, redirects...)
+	}
+
+	r := &response{
+		Body:       body,
+		Decoded:    decoded,
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		FinalURL:   resp.Request.URL.String(),
+		Redirects:  redirects,
+		Truncated:  s.opts.FollowRedirects && len(redirects) >= s.opts.MaxRedirects && resp.StatusCode/100 == 3 && resp.Header.Get("Location") != "",
+		Timings:    trace.Timings(),
+		Request:    resp.Request,
+	}
+	s.hostCache.RecordResponse(target.URL, latency, detectWAF(r))
+	return r, nil
+}
+
+// getDOM renders target in the headless browser, subject to rate limits.
+func (s *Scanner) getDOM(target utils.Target) (string, error) {
+	s.limiter.Wait(target.URL)
+	s.explainRequest("DOM", target)
+	return s.domScanner.GetDOM(target)
+}
+
+// getDOMSent is getDOM that also returns the request target the browser
+// sent for the page.
+func (s *Scanner) getDOMSent(target utils.Target) (string, string, error) {
+	s.limiter.Wait(target.URL)
+	s.explainRequest("DOM", target)
+	return s.domScanner.getDOMSent(target)
+}
+
+// isGet reports whether target is a plain GET request that the headless
+// browser can replay.
+func isGet(target utils.Target) bool {
+	return (target.Method == "" || target.Method == http.MethodGet) && target.Body == ""
+}
+
+// saveEvidence stores the reflecting body and the HTTP exchange of resp,
+// plus a screenshot of the page for DOM reflections, when an artifacts
+// directory is configured.
+func (s *Scanner) saveEvidence(target utils.Target, resp *response, body string, reflectedInDOM bool) {
+	if s.artifacts == nil {
+		return
+	}
+	kind := "http"
+	if reflectedInDOM {
+		kind = "dom"
+	}
+	s.saveArtifact(target, kind, "reflected-"+kind+".html", []byte(body))
+	s.saveArtifact(target, "exchange", "exchange.txt", httpExchange(resp, target.Body))
+	if reflectedInDOM {
+		s.limiter.Wait(target.URL)
+		s.explainRequest("DOM", target)
+		shot, err := s.domScanner.screenshot(target)
+		if err != nil {
+			if s.opts.Verbose {
+				fmt.Printf("Error taking screenshot: %v\n", err)
+			}
+			return
+		}
+		s.saveArtifact(target, "screenshot", "screenshot.png", shot)
+	}
+}
+
+// saveArtifact saves one piece of evidence for target.
+func (s *Scanner) saveArtifact(target utils.Target, kind, name string, data []byte) {
+	path, err := s.artifacts.Save(target.URL, target.Param, kind, name, data)
+	if err != nil {
+		if s.opts.Verbose {
+			fmt.Printf("Error saving evidence: %v\n", err)
+		}
+		return
+	}
+	if s.opts.Verbose && !s.opts.JSONOutput {
+		fmt.Printf("EVIDENCE: %s\n", path)
+	}
+}
+
+func (s *Scanner) printRedirects(resp *response) {
+	if s.opts.JSONOutput {
+		return
+	}
+	chain := strings.Join(append(append([]string{}, resp.Redirects...), resp.FinalURL), " ➔ ")
+	if resp.Truncated {
+		chain += fmt.Sprintf(" (stopped at --max-redirects %d)", s.opts.MaxRedirects)
+	}
+	if s.opts.NoColor {
+		fmt.Printf("REDIRECTED: %s\n", chain)
+	} else {
+		fmt.Printf("\033[93mREDIRECTED: %s\033[0m\n", chain)
+	}
+}
+
+func (s *Scanner) printSkipped(reason string) {
+	if s.opts.JSONOutput {
+		return
+	}
+	if s.opts.NoColor {
+		fmt.Printf("SKIPPED: %s\n", reason)
+	} else {
+		fmt.Printf("\033[90mSKIPPED: %s\033[0m\n", reason)
+	}
+}
+
+func (s *Scanner) printErrorPage(status int) {
+	if s.opts.JSONOutput {
+		return
+	}
+	if s.opts.NoColor {
+		fmt.Printf("ERROR PAGE: status %d\n", status)
+	} else {
+		fmt.Printf("\033[93mERROR PAGE: status %d\033[0m\n", status)
+	}
+}
+
+func (s *Scanner) printSetCookie(names []string) {
+	if s.opts.JSONOutput {
+		return
+	}
+	if s.opts.NoColor {
+		fmt.Printf("SET-COOKIE REFLECTION: %v\n", names)
+	} else {
+		fmt.Printf("\033[93mSET-COOKIE REFLECTION: %v\033[0m\n", names)
+	}
+}
+
+func (s *Scanner) printMimeSniffing(hint *MimeSniffHint) {
+	if hint == nil || s.opts.JSONOutput {
+		return
+	}
+	contentType := hint.ContentType
+	if contentType == "" {
+		contentType = "none"
+	}
+	if s.opts.NoColor {
+		fmt.Printf("MIME SNIFFING: possible (Content-Type: %s, no nosniff)\n", contentType)
+	} else {
+		fmt.Printf("\033[93mMIME SNIFFING: possible (Content-Type: %s, no nosniff)\033[0m\n", contentType)
+	}
+	for _, c := range hint.Conditions {
+		fmt.Printf("  - %s\n", c)
+	}
+}
+
+func (s *Scanner) printBaseURL(label string) {
+	if s.opts.NoColor {
+		fmt.Printf("BASEURL: %s\n", label)
+	} else {
+		fmt.Printf("\033[94mBASEURL: %s\033[0m\n", label)
+	}
+}
+
+func (s *Scanner) printReflected(reflected bool) {
+	if s.opts.JSONOutput {
+		return
+	}
+	if reflected {
+		if s.opts.NoColor {
+			fmt.Println("REFLECTED: YES")
+		} else {
+			fmt.Println("\033[92mREFLECTED: YES\033[0m")
+		}
+	} else {
+		if s.opts.NoColor {
+			fmt.Println("REFLECTED: NO")
+		} else {
+			fmt.Println("\033[91mREFLECTED: NO\033[0m")
+		}
+	}
+}
+
+func (s *Scanner) printJSON(output JSONOutput) {
+	if !s.opts.JSONOutput && s.writer == nil {
+		return
+	}
+	// Initialize empty slices if nil to ensure JSON output is consistent [] instead of null
+	if output.Allowed == nil { output.Allowed = []string{} }
+	if output.Blocked == nil { output.Blocked = []string{} }
+	if output.Stripped == nil { output.Stripped = []string{} }
+	if output.Converted == nil { output.Converted = []string{} }
+	output.SchemaVersion = SchemaVersion
+	if output.Count == nil { output.Count = map[string]int{"allowed": 0, "blocked": 0, "stripped": 0, "converted": 0} }
+	if !s.filter.Match(output) {
+		return
+	}
+
+	if s.writer != nil {
+		if err := s.writer.Write(output); err != nil && s.opts.Verbose {
+			fmt.Printf("Error writing output: %v\n", err)
+		}
+	}
+	if s.filterText {
+		s.printFiltered(output)
+		return
+	}
+	if !s.opts.JSONOutput {
+		return
+	}
+
+	jsonBytes, _ := json.MarshalIndent(output, "", "  ")
+	fmt.Println(string(jsonBytes))
+}
+
+// DOMScanner handles headless browser interactions
+type DOMScanner struct {
+	allocCtx    context.Context
+	allocCancel context.CancelFunc
+	ctx         context.Context
+	ctxCancel   context.CancelFunc
+	startOnce   sync.Once
+	startErr    error
+	jar         http.CookieJar
+	cookies     []*http.Cookie
+	authHeader  string
+	headerCmd   *headerCommand
+	maxDOMSize  int64
+	dialProxy   net.Listener
+
+	// hosts counts the requests the browser sends, when set. With
+	// forbidOffscope, page loads leaving the target's host are refused as
+	// redirects are by the HTTP client.
+	hosts          *contactedHosts
+	forbidOffscope bool
+}
+
+// NewDOMScanner prepares a headless Chrome instance, started on first use,
+// that sends the same cookies and credentials as the HTTP client.
+func NewDOMScanner(scanOpts Options, jar http.CookieJar, cookies []*http.Cookie) (*DOMScanner, error) {
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("headless", true),
+		chromedp.Flag("disable-gpu", true),
+		chromedp.Flag("no-sandbox", true),
+		chromedp.Flag("disable-dev-shm-usage", true),
+	)
+
+	if !scanOpts.VerifySSL {
+		opts = append(opts, chromedp.Flag("ignore-certificate-errors", true))
+	}
+
+	if scanOpts.Proxy != "" {
+		server, dropped := browserProxy(scanOpts.Proxy)
+		if dropped {
+			fmt.Fprintln(os.Stderr, "WARNING: the headless browser cannot authenticate to a SOCKS proxy; DOM checks will fail to load pages through it")
+		}
+		opts = append(opts, chromedp.ProxyServer(server))
+	}
+
+	if scanOpts.HTTPVersion == "1.1" {
+		opts = append(opts, chromedp.Flag("disable-http2", true))
+	}
+
+	overrides, err := parseResolve(scanOpts.Resolve)
+	if err != nil {
+		return nil, err
+	}
+	authHeader, err := authorizationHeader(scanOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Chrome cannot be given a DNS server, so with --dns-server it goes
+	// through a local proxy that resolves, overrides included, like the
+	// HTTP client
+	var dialProxy net.Listener
+	switch {
+	case scanOpts.DNSServer != "" && scanOpts.Proxy == "":
+		dialer := newDialer(scanOpts.DNSServer)
+		if dialProxy, err = startDialProxy(overrideDial(dialer.DialContext, overrides)); err != nil {
+			return nil, err
+		}
+		opts = append(opts, chromedp.ProxyServer("http://"+dialProxy.Addr().String()))
+	case len(overrides) > 0:
+		opts = append(opts, chromedp.Flag("host-resolver-rules", hostResolverRules(overrides)))
+	}
+
+	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
+	ctx, ctxCancel := chromedp.NewContext(allocCtx)
+
+	return &DOMScanner{
+		allocCtx:    allocCtx,
+		allocCancel: allocCancel,
+		ctx:         ctx,
+		ctxCancel:   ctxCancel,
+		jar:         jar,
+		cookies:     cookies,
+		authHeader:  authHeader,
+		maxDOMSize:  scanOpts.MaxDOMSize,
+		dialProxy:   dialProxy,
+	}, nil
+}
+
+// Close shuts the browser down.
+func (s *DOMScanner) Close() {
+	s.ctxCancel()
+	s.allocCancel()
+	if s.dialProxy != nil {
+		s.dialProxy.Close()
+	}
+}
+
+// GetDOM loads target in a new tab and returns the rendered HTML, or only
+// the nodes containing the injected canary when the page is larger than
+// Options.MaxDOMSize.
+func (s *DOMScanner) GetDOM(target utils.Target) (string, error) {
+	dom, _, err := s.getDOMSent(target)
+	return dom, err
+}
+
+// getDOMSent is GetDOM that also returns the request target on the request
+// line of the page load, as the browser escaped it.
+func (s *DOMScanner) getDOMSent(target utils.Target) (string, string, error) {
+	var mu sync.Mutex
+	var dom, sent string
+	err := s.load(target, func(ev any) {
+		// The navigation request shares its ID with its loader; redirects
+		// reuse it, so the first one is what was sent for target
+		req, ok := ev.(*network.EventRequestWillBeSent)
+		if !ok || req.Type != network.ResourceTypeDocument || string(req.RequestID) != string(req.LoaderID) {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if u, err := url.Parse(req.Request.URL); err == nil && sent == "" {
+			sent = u.RequestURI()
+		}
+	}, serializeDOM(s.maxDOMSize, domNeedle(target.Payload), &dom))
+	if err != nil {
+		return "", "", err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	return dom, sent, nil
+}
+
+// load navigates a new tab to target, waits for the page to settle and then
+// runs actions. JavaScript dialogs are dismissed so they cannot block the
+// page; they and every other event of the tab are passed to listen, if set.
+func (s *DOMScanner) load(target utils.Target, listen func(ev any), actions ...chromedp.Action) error {
+	if !isGet(target) {
+		return errors.New("DOM checks only support GET requests")
+	}
+
+	// Start the browser once so every navigation can get its own tab
+	s.startOnce.Do(func() {
+		s.startErr = chromedp.Run(s.ctx)
+	})
+	if s.startErr != nil {
+		return s.startErr
+	}
+
+	// Each navigation runs in a tab of its own browser context, which has its
+	// own cookie store, so cookies set for one target don't leak into later
+	// navigations or those of concurrent workers
+	tabCtx, tabCancel := chromedp.NewContext(s.ctx, chromedp.WithNewBrowserContext())
+	defer tabCancel()
+	// Create a timeout context for the navigation
+	ctx, cancel := context.WithTimeout(tabCtx, 30*time.Second)
+	defer cancel()
+
+	first, err := url.Parse(target.URL)
+	if err != nil {
+		return err
+	}
+	chromedp.ListenTarget(tabCtx, func(ev any) {
+		// Sending commands from the listener itself would deadlock
+		switch ev := ev.(type) {
+		case *page.EventJavascriptDialogOpening:
+			go chromedp.Run(tabCtx, page.HandleJavaScriptDialog(false))
+		case *network.EventRequestWillBeSent:
+			s.recordRequest(ev.Request.URL, ev.Type, first)
+		case *fetch.EventRequestPaused:
+			// Only page loads are paused, with --forbid-offscope-redirects
+			if u, err := url.Parse(ev.Request.URL); err == nil && offScope(u, first) {
+				if s.hosts != nil {
+					s.hosts.Refuse(u)
+				}
+				go chromedp.Run(tabCtx, fetch.FailRequest(ev.RequestID, network.ErrorReasonBlockedByClient))
+			} else {
+				go chromedp.Run(tabCtx, fetch.ContinueRequest(ev.RequestID))
+			}
+		}
+		if listen != nil {
+			listen(ev)
+		}
+	})
+
+	headers := network.Headers{}
+	if s.authHeader != "" {
+		headers["Authorization"] = s.authHeader
+	}
+	cmdHeaders, _ := s.headerCmd.Headers()
+	for k, v := range cmdHeaders {
+		headers[k] = v
+	}
+	for k, v := range target.Headers {
+		headers[k] = v
+	}
+
+	setup := []chromedp.Action{
+		network.Enable(),
+		network.SetExtraHTTPHeaders(headers),
+		s.setCookies(target),
+	}
+	if s.forbidOffscope {
+		setup = append(setup, fetch.Enable().WithPatterns([]*fetch.RequestPattern{{URLPattern: "*", ResourceType: network.ResourceTypeDocument}}))
+	}
+	setup = append(setup,
+		chromedp.Navigate(target.URL),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			// Simple wait for network idle or just a small delay
+			// Using a fixed delay for simplicity as network idle can be flaky
+			time.Sleep(2 * time.Second)
+			return nil
+		}),
+	)
+	return chromedp.Run(ctx, append(setup, actions...)...)
+}
+
+// screenshot loads target in the browser and captures the viewport as PNG.
+func (s *DOMScanner) screenshot(target utils.Target) ([]byte, error) {
+	var shot []byte
+	err := s.load(target, nil, chromedp.CaptureScreenshot(&shot))
+	return shot, err
+}
+
+// recordRequest counts a request sent by the browser towards the contacted
+// hosts, except page loads that forbidOffscope refuses.
+func (s *DOMScanner) recordRequest(rawURL string, typ network.ResourceType, first *url.URL) {
+	u, err := url.Parse(rawURL)
+	if err != nil || s.hosts == nil || u.Scheme != "http" && u.Scheme != "https" {
+		return
+	}
+	if s.forbidOffscope && typ == network.ResourceTypeDocument && offScope(u, first) {
+		return
+	}
+	s.hosts.Add(u)
+}
+
+// setCookies installs the configured cookies for targetURL in the browser
+// before navigating, so DOM checks run with the same session as HTTP checks.
+func (s *DOMScanner) setCookies(target utils.Target) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		for _, c := range cookiesFor(s.jar, s.cookies, target.URL, target.Cookies) {
+			if err := network.SetCookie(c.Name, c.Value).WithURL(target.URL).Do(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,92 @@
+package scanner
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// SchemaVersion is the version of the result record format, carried by
+// every record as schema_version. Fields are only ever added within a major
+// version, which bumps the minor version; removing, renaming or changing
+// the type or meaning of a field bumps the major version. Parsers should
+// ignore fields they don't know.
+const SchemaVersion = "1.6.0"
+
+// Schema returns the JSON Schema of result records. It is derived from
+// JSONOutput itself, so it always matches what the scanner writes.
+func Schema() map[string]any {
+	schema := typeSchema(reflect.TypeFor[JSONOutput]())
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "xssrecon result"
+	schema["description"] = "One checked injection point, as written to --output files and printed with --json. Schema version " + SchemaVersion + "."
+	major, _, _ := strings.Cut(SchemaVersion, ".")
+	props := schema["properties"].(map[string]any)
+	props["schema_version"] = map[string]any{"type": "string", "pattern": `^` + major + `\.`}
+	return schema
+}
+
+// checkSchemaVersion reports an error unless version has the major version
+// of SchemaVersion, the only one this build can read.
+func checkSchemaVersion(version string) error {
+	major, _, _ := strings.Cut(SchemaVersion, ".")
+	if version == "" {
+		return fmt.Errorf("missing schema_version, expected %s.x", major)
+	}
+	if got, _, _ := strings.Cut(version, "."); got != major {
+		return fmt.Errorf("unsupported schema_version %s, expected %s.x", version, major)
+	}
+	return nil
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// typeSchema returns the JSON Schema of values of type t as encoding/json
+// writes them.
+func typeSchema(t reflect.Type) map[string]any {
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		props := make(map[string]any)
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if !f.IsExported() || tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			if name == "" {
+				name = f.Name
+			}
+			props[name] = typeSchema(f.Type)
+			if !strings.Contains(","+opts+",", ",omitempty,") {
+				required = append(required, name)
+			}
+		}
+		schema := map[string]any{"type": "object", "properties": props}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+	return map[string]any{}
+}
@@ -0,0 +1,174 @@
+package scanner
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+)
+
+//go:embed serve_report.html
+var reportPage []byte
+
+// reportFinding is a result record as served to the report viewer, with
+// what the table needs precomputed.
+type reportFinding struct {
+	JSONOutput
+	Severity string `json:"severity,omitempty"`
+	// PoC is the base URL with the canary replaced by the first suggested
+	// payload, or the base URL itself when there is none. It is empty when
+	// the finding cannot be reproduced by opening a URL.
+	PoC      string          `json:"poc"`
+	Evidence []ArtifactEntry `json:"evidence,omitempty"`
+}
+
+// ReportServer serves a local web UI over a results file: a filterable
+// findings table, the saved evidence of each finding and its PoC URL. The
+// results file and artifacts directory are re-read on every request, so
+// the view follows a scan that is still running.
+type ReportServer struct {
+	results   string
+	artifacts string
+}
+
+// NewReportServer serves the records in the results file at path (as
+// written by --output or --json) and, when artifactsDir is set, the
+// evidence saved there by --artifacts-dir.
+func NewReportServer(results, artifactsDir string) (*ReportServer, error) {
+	if _, err := os.Stat(results); err != nil {
+		return nil, fmt.Errorf("opening results: %w", err)
+	}
+	return &ReportServer{results: results, artifacts: artifactsDir}, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (rs *ReportServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Evidence is attacker-influenced markup; nothing served here may run
+	// script except the viewer's own
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	switch {
+	case r.URL.Path == "/":
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'unsafe-inline'; style-src 'unsafe-inline'")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(reportPage)
+	case r.URL.Path == "/api/findings":
+		rs.serveFindings(w)
+	case strings.HasPrefix(r.URL.Path, "/evidence/"):
+		rs.serveEvidence(w, strings.TrimPrefix(r.URL.Path, "/evidence/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (rs *ReportServer) serveFindings(w http.ResponseWriter) {
+	results, err := LoadFindings(rs.results)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	evidence := rs.loadEvidence()
+
+	findings := make([]reportFinding, 0, len(results))
+	for _, r := range results {
+		f := reportFinding{JSONOutput: r, PoC: proofOfConcept(r)}
+		if r.Reflected {
+			f.Severity = findingSeverity(r)
+		}
+		f.Evidence = evidence[r.BaseURL+"\x00"+r.Param]
+		findings = append(findings, f)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.Encode(findings)
+}
+
+// loadEvidence reads the index.json of every host in the artifacts
+// directory, keyed by target URL and parameter.
+func (rs *ReportServer) loadEvidence() map[string][]ArtifactEntry {
+	evidence := make(map[string][]ArtifactEntry)
+	if rs.artifacts == "" {
+		return evidence
+	}
+	indexes, _ := filepath.Glob(filepath.Join(rs.artifacts, "*", "index.json"))
+	for _, index := range indexes {
+		data, err := os.ReadFile(index)
+		if err != nil {
+			continue
+		}
+		var entries []ArtifactEntry
+		if json.Unmarshal(data, &entries) != nil {
+			continue
+		}
+		for _, e := range entries {
+			key := e.URL + "\x00" + e.Param
+			evidence[key] = append(evidence[key], e)
+		}
+	}
+	return evidence
+}
+
+// serveEvidence serves a file from the artifacts directory: screenshots as
+// images, everything else as plain text so saved pages are shown rather
+// than rendered.
+func (rs *ReportServer) serveEvidence(w http.ResponseWriter, name string) {
+	if rs.artifacts == "" || !fs.ValidPath(name) {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	data, err := os.ReadFile(filepath.Join(rs.artifacts, filepath.FromSlash(name)))
+	if errors.Is(err, fs.ErrNotExist) {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if filepath.Ext(name) == ".png" {
+		w.Header().Set("Content-Type", "image/png")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	w.Write(data)
+}
+
+// proofOfConcept returns the base URL of a finding with the canary swapped
+// for its first suggested payload, encoded for the part of the URL the
+// canary is in. Findings in a request body, header, cookie or base64 value,
+// or sent with another method than GET, have no PoC URL.
+func proofOfConcept(r JSONOutput) string {
+	if r.Request != nil && r.Request.Method != http.MethodGet {
+		return ""
+	}
+	for _, prefix := range []string{utils.BodyParamPrefix, utils.HeaderParamPrefix, utils.CookieParamPrefix, utils.Base64ParamPrefix} {
+		if strings.HasPrefix(r.Param, prefix) {
+			return ""
+		}
+	}
+	if len(r.Suggestions) == 0 || r.Canary == "" {
+		return r.BaseURL
+	}
+	u, err := url.Parse(r.BaseURL)
+	if err != nil {
+		return ""
+	}
+	component := utils.ComponentQuery
+	switch {
+	case strings.Contains(u.EscapedFragment(), r.Canary):
+		component = utils.ComponentFragment
+	case strings.Contains(u.EscapedPath(), r.Canary):
+		component = utils.ComponentPath
+	}
+	payload := utils.EncodeURLComponent(r.Suggestions[0].Payload, component, utils.EncodeAlways)
+	return strings.Replace(r.BaseURL, r.Canary, payload, 1)
+}
@@ -0,0 +1,26 @@
+package scanner
+
+import (
+	"net/http"
+	"strings"
+)
+
+// setCookieReflections returns the names of the cookies set by resp whose
+// value contains canary. Input that lands in a cookie can enable session
+// fixation or a stored XSS chain wherever the cookie is later rendered.
+func setCookieReflections(resp *response, canary string) []string {
+	var names []string
+	for _, line := range resp.Header.Values("Set-Cookie") {
+		if !strings.Contains(line, canary) {
+			continue
+		}
+		name := line
+		if c, err := http.ParseSetCookie(line); err == nil {
+			name = c.Name
+		} else if i := strings.Index(line, "="); i > 0 {
+			name = line[:i]
+		}
+		names = append(names, name)
+	}
+	return names
+}
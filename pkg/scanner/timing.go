@@ -0,0 +1,72 @@
+package scanner
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// phaseTimings are the durations of the phases of one HTTP request, in
+// milliseconds. Connect and TLS are zero when a kept-alive connection was
+// reused.
+type phaseTimings struct {
+	ConnectMs   int64
+	TLSMs       int64
+	FirstByteMs int64
+}
+
+// requestTrace times the phases of a request as the transport reports them.
+// Dial attempts may run in parallel, hence the lock.
+type requestTrace struct {
+	mu           sync.Mutex
+	start        time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	timings      phaseTimings
+}
+
+// traceRequest returns req instrumented to record its phase timings.
+func traceRequest(req *http.Request) (*http.Request, *requestTrace) {
+	t := &requestTrace{start: time.Now()}
+	trace := &httptrace.ClientTrace{
+		ConnectStart: func(string, string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if t.connectStart.IsZero() {
+				t.connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(_, _ string, err error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if err == nil && t.timings.ConnectMs == 0 {
+				t.timings.ConnectMs = time.Since(t.connectStart).Milliseconds()
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.timings.TLSMs = time.Since(t.tlsStart).Milliseconds()
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.timings.FirstByteMs = time.Since(t.start).Milliseconds()
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), t
+}
+
+// Timings returns the phase timings recorded so far.
+func (t *requestTrace) Timings() phaseTimings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.timings
+}
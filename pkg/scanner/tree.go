@@ -0,0 +1,147 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TreeNode is a host or path segment in the tree of reflecting endpoints.
+// Reflections counts the reflected injection points at or below the node,
+// Params those at exactly this path.
+type TreeNode struct {
+	Name        string      `json:"name"`
+	Reflections int         `json:"reflections"`
+	Params      []string    `json:"params,omitempty"`
+	Children    []*TreeNode `json:"children,omitempty"`
+}
+
+func (n *TreeNode) child(name string) *TreeNode {
+	for _, c := range n.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+	c := &TreeNode{Name: name}
+	n.Children = append(n.Children, c)
+	return c
+}
+
+// sort orders children by name at every level, so output is stable.
+func (n *TreeNode) sort() {
+	sort.Slice(n.Children, func(i, j int) bool { return n.Children[i].Name < n.Children[j].Name })
+	sort.Strings(n.Params)
+	for _, c := range n.Children {
+		c.sort()
+	}
+}
+
+// buildTree groups reflected findings by host and path segment. The input
+// URL is used rather than the probed one, so canaries injected into the
+// path do not split the tree.
+func buildTree(findings []JSONOutput) *TreeNode {
+	root := &TreeNode{Name: "/"}
+	for _, f := range findings {
+		u, err := url.Parse(f.Processing)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		node := root.child(u.Host)
+		root.Reflections++
+		node.Reflections++
+		for _, seg := range strings.Split(strings.Trim(u.Path, "/"), "/") {
+			if seg == "" {
+				continue
+			}
+			node = node.child(seg)
+			node.Reflections++
+		}
+		if !slices.Contains(node.Params, f.Param) {
+			node.Params = append(node.Params, f.Param)
+		}
+	}
+	root.sort()
+	return root
+}
+
+// TreeWriter collects the reflected findings of a scan and, on Close,
+// writes them as a host/path tree: JSON, or a Graphviz digraph when the
+// path ends in ".dot".
+type TreeWriter struct {
+	path string
+
+	mu       sync.Mutex
+	findings []JSONOutput
+}
+
+// NewTreeWriter writes the reflecting endpoints to path on Close.
+func NewTreeWriter(path string) *TreeWriter {
+	return &TreeWriter{path: path}
+}
+
+func (w *TreeWriter) Write(output JSONOutput) error {
+	if !output.Reflected {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.findings = append(w.findings, output)
+	return nil
+}
+
+func (w *TreeWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	root := buildTree(w.findings)
+
+	var data []byte
+	if strings.HasSuffix(w.path, ".dot") {
+		data = []byte(renderDot(root))
+	} else {
+		var err error
+		if data, err = json.MarshalIndent(root, "", "  "); err != nil {
+			return err
+		}
+		data = append(data, '\n')
+	}
+	if err := os.WriteFile(w.path, data, 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", w.path, err)
+	}
+	return nil
+}
+
+// renderDot draws the tree as a left-to-right Graphviz digraph, with each
+// node labelled by its name, reflection count and parameters.
+func renderDot(root *TreeNode) string {
+	var b strings.Builder
+	b.WriteString("digraph reflections {\n\trankdir=LR;\n\tnode [shape=box];\n")
+	id := 0
+	var walk func(n *TreeNode) int
+	walk = func(n *TreeNode) int {
+		me := id
+		id++
+		label := fmt.Sprintf("%s\\n%d reflecting", n.Name, n.Reflections)
+		if len(n.Params) > 0 {
+			label += "\\n" + strings.Join(n.Params, ", ")
+		}
+		fmt.Fprintf(&b, "\tn%d [label=%s];\n", me, dotQuote(label))
+		for _, c := range n.Children {
+			fmt.Fprintf(&b, "\tn%d -> n%d;\n", me, walk(c))
+		}
+		return me
+	}
+	walk(root)
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotQuote quotes s as a DOT string, keeping the \n line breaks of labels.
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
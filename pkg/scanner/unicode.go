@@ -0,0 +1,89 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+)
+
+// unicodeVariants maps each special character to look-alike code points
+// (full-width forms, small form variants and other confusables) that
+// NFKC normalization or best-fit code page conversion turn back into the
+// ASCII character. A backend that filters first and normalizes afterwards
+// lets them through as the dangerous character. Every character is also
+// probed percent-encoded in full-width characters; see fullWidthPercent.
+var unicodeVariants = map[string][]string{
+	`'`: {"＇", "‘", "’"},
+	`"`: {"＂", "“", "”"},
+	`<`: {"＜", "﹤", "‹"},
+	`>`: {"＞", "﹥", "›"},
+	`(`: {"（", "﹙"},
+	`)`: {"）", "﹚"},
+	"`": {"｀"},
+	`{`: {"｛", "﹛"},
+	`}`: {"｝", "﹜"},
+	`/`: {"／", "∕"},
+	`\`: {"＼", "﹨"},
+	`;`: {"；", "﹔"},
+}
+
+// fullWidthPercent writes char percent-encoded in full-width characters,
+// e.g. "％３Ｃ" for "<". NFKC turns it into "%3C", which a backend that
+// URL-decodes afterwards turns into the character itself.
+func fullWidthPercent(char string) string {
+	var b strings.Builder
+	for _, c := range fmt.Sprintf("%%%02X", char[0]) {
+		b.WriteRune(c + 0xFEE0)
+	}
+	return b.String()
+}
+
+// unicodeProbe sends the confusable variants of every special character and
+// its full-width percent-encoding, and reports each one that comes back as
+// its ASCII counterpart, formatted as "＜ (U+FF1C) ➔ <". Full-width
+// percent-encodings are reported with the form they were normalized to:
+// "％３Ｃ (full-width %3C) ➔ <" when also decoded, "➔ %3C" when not.
+func (s *Scanner) unicodeProbe(req *utils.Request, target utils.Target, reflectedInDOM bool) []string {
+	normalized := []string{}
+	for _, char := range s.chars {
+		for _, variant := range unicodeVariants[char] {
+			if s.variantReflectsAs(req, target, reflectedInDOM, variant, char) != "" {
+				normalized = append(normalized, fmt.Sprintf("%s (U+%04X) ➔ %s", variant, []rune(variant)[0], char))
+			}
+		}
+		variant, pct := fullWidthPercent(char), fmt.Sprintf("%%%02X", char[0])
+		if form := s.variantReflectsAs(req, target, reflectedInDOM, variant, char, pct); form != "" {
+			normalized = append(normalized, fmt.Sprintf("%s (full-width %s) ➔ %s", variant, pct, form))
+		}
+	}
+	return normalized
+}
+
+// variantReflectsAs sends variant behind the canary and returns the first of
+// forms the canary comes back followed by, or "" if none.
+func (s *Scanner) variantReflectsAs(req *utils.Request, target utils.Target, reflectedInDOM bool, variant string, forms ...string) string {
+	s.resetState(req)
+	canary := s.newCanary(target.URL, target.Param)
+	testTarget, ok := s.probeTarget(req, target.Param, canary+variant)
+	if !ok {
+		return ""
+	}
+
+	var body string
+	var err error
+	if reflectedInDOM {
+		body, err = s.getDOM(testTarget)
+	} else {
+		body, err = s.fetch(testTarget)
+	}
+	if err != nil {
+		return ""
+	}
+	for _, form := range forms {
+		if s.reflectsWith(body, canary, form) {
+			return form
+		}
+	}
+	return ""
+}
@@ -0,0 +1,153 @@
+package scanner
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+)
+
+// wafSignatures recognize the block and challenge pages of common WAFs.
+// Only responses that refuse the request match; a WAF that lets the probe
+// through is not reported.
+var wafSignatures = []struct {
+	name  string
+	match func(resp *response) bool
+}{
+	{"cloudflare", func(resp *response) bool {
+		cf := resp.Header.Get("Cf-Ray") != "" || strings.EqualFold(resp.Header.Get("Server"), "cloudflare")
+		return cf && (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusServiceUnavailable) &&
+			(strings.Contains(resp.Body, "Attention Required! | Cloudflare") ||
+				strings.Contains(resp.Body, "Just a moment...") ||
+				strings.Contains(resp.Body, "cf-error-details") ||
+				strings.Contains(resp.Body, "/cdn-cgi/challenge-platform/"))
+	}},
+	{"akamai", func(resp *response) bool {
+		return resp.StatusCode == http.StatusForbidden &&
+			(strings.HasPrefix(resp.Header.Get("Server"), "AkamaiGHost") ||
+				strings.Contains(resp.Body, "Access Denied") && strings.Contains(resp.Body, "Reference&#32;&#35;"))
+	}},
+	{"aws-waf", func(resp *response) bool {
+		if resp.Header.Get("X-Amzn-Waf-Action") != "" {
+			return true
+		}
+		return resp.StatusCode == http.StatusForbidden &&
+			(strings.HasPrefix(resp.Header.Get("Server"), "awselb") ||
+				resp.Header.Get("X-Amz-Cf-Id") != "" && strings.Contains(resp.Body, "Request blocked"))
+	}},
+}
+
+// detectWAF returns the name of the WAF whose block page resp is, or "".
+func detectWAF(resp *response) string {
+	for _, sig := range wafSignatures {
+		if sig.match(resp) {
+			return sig.name
+		}
+	}
+	return ""
+}
+
+// Pacing applied by --waf-adapt to hosts whose WAF blocked a probe: the
+// delay before each request starts at wafMinDelay and doubles with every
+// further block, up to wafMaxDelay.
+const (
+	wafMinDelay = time.Second
+	wafMaxDelay = 10 * time.Second
+)
+
+// wafPacer slows down requests to hosts that answered with WAF block pages.
+// A nil pacer does nothing.
+type wafPacer struct {
+	mu     sync.Mutex
+	delays map[string]time.Duration
+}
+
+func newWAFPacer(enabled bool) *wafPacer {
+	if !enabled {
+		return nil
+	}
+	return &wafPacer{delays: make(map[string]time.Duration)}
+}
+
+// Blocked records a WAF block from rawURL's host and raises its delay.
+func (p *wafPacer) Blocked(rawURL string) {
+	if p == nil {
+		return
+	}
+	host := wafHost(rawURL)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	d := p.delays[host] * 2
+	p.delays[host] = min(max(d, wafMinDelay), wafMaxDelay)
+}
+
+// Wait sleeps for the current delay of rawURL's host.
+func (p *wafPacer) Wait(rawURL string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	d := p.delays[wafHost(rawURL)]
+	p.mu.Unlock()
+	time.Sleep(d)
+}
+
+func wafHost(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		return u.Host
+	}
+	return rawURL
+}
+
+// wafBypass re-sends each WAF-blocked character raw and double-encoded and
+// returns, by character, the first variant that came back unmodified.
+func (s *Scanner) wafBypass(req *utils.Request, target utils.Target, reflectedInDOM bool, chars []string) map[string]string {
+	bypassed := make(map[string]string)
+	for _, char := range chars {
+		variants := []struct {
+			name    string
+			payload func(canary string) string
+			mode    utils.EncodeMode
+		}{
+			{"raw", func(c string) string { return c + char }, utils.EncodeNever},
+			{"double-encoded", func(c string) string { return c + url.QueryEscape(char) }, utils.EncodeAlways},
+		}
+		for _, v := range variants {
+			s.resetState(req)
+			canary := s.newCanary(target.URL, target.Param)
+			testTarget, ok := s.probeTargetEncoded(req, target.Param, v.payload(canary), v.mode)
+			if !ok {
+				continue
+			}
+			body, probe, err := s.sendProbe(testTarget, char, reflectedInDOM)
+			if err != nil || probe.WAF != "" {
+				continue
+			}
+			if verdict, _ := s.classifyChar(body, canary, char); verdict == "allowed" {
+				bypassed[char] = v.name
+				break
+			}
+		}
+	}
+	return bypassed
+}
+
+// printWAF reports a WAF block of the base request or of some probes.
+func (s *Scanner) printWAF(name string, blocked []string) {
+	if s.opts.JSONOutput || name == "" {
+		return
+	}
+	line := "WAF BLOCKED: " + name
+	if len(blocked) > 0 {
+		line += fmt.Sprintf(" %v", printableChars(blocked))
+	}
+	if s.opts.NoColor {
+		fmt.Println(line)
+	} else {
+		fmt.Printf("\033[91m%s\033[0m\n", line)
+	}
+}
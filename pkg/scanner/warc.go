@@ -0,0 +1,219 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytes-Knight/xssrecon/pkg/utils"
+)
+
+// WARC header fields that tie each recorded response to the probe that
+// produced it, so analyze can classify it again without the target.
+const (
+	warcInputHeader      = "WARC-Xssrecon-Input"
+	warcParamHeader      = "WARC-Xssrecon-Param"
+	warcPayloadHeader    = "WARC-Xssrecon-Payload"
+	warcDecodeJSONHeader = "WARC-Xssrecon-Decode-JSON"
+)
+
+// WARCWriter records HTTP probe traffic as WARC/1.1 request and response
+// records.
+type WARCWriter struct {
+	mu   sync.Mutex
+	file *os.File
+	w    *bufio.Writer
+}
+
+// NewWARCWriter records probe traffic to the WARC file at path.
+func NewWARCWriter(path string) (*WARCWriter, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("creating record directory: %w", err)
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening record file: %w", err)
+	}
+	w := &WARCWriter{file: f, w: bufio.NewWriter(f)}
+	if err := w.writeRecord("warcinfo", "", "application/warc-fields", nil, []byte("software: xssrecon\r\nformat: WARC File Format 1.1\r\n")); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Record writes the request for target and the response it got. A nil
+// *WARCWriter records nothing.
+func (w *WARCWriter) Record(req *http.Request, target utils.Target, resp *http.Response, body []byte) error {
+	if w == nil {
+		return nil
+	}
+	var reqBlock bytes.Buffer
+	fmt.Fprintf(&reqBlock, "%s %s HTTP/1.1\r\nHost: %s\r\n", req.Method, req.URL.RequestURI(), req.URL.Host)
+	req.Header.Write(&reqBlock)
+	reqBlock.WriteString("\r\n")
+	reqBlock.WriteString(target.Body)
+
+	var respBlock bytes.Buffer
+	fmt.Fprintf(&respBlock, "HTTP/1.1 %s\r\n", resp.Status)
+	header := resp.Header.Clone()
+	header.Del("Content-Length")
+	header.Del("Transfer-Encoding")
+	header.Write(&respBlock)
+	fmt.Fprintf(&respBlock, "Content-Length: %d\r\n\r\n", len(body))
+	respBlock.Write(body)
+
+	extra := [][2]string{
+		{warcInputHeader, target.Input},
+		{warcParamHeader, target.Param},
+		{warcPayloadHeader, strconv.Quote(target.Payload)},
+	}
+	if target.DecodeJSON {
+		extra = append(extra, [2]string{warcDecodeJSONHeader, "true"})
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.writeRecord("request", req.URL.String(), "application/http;msgtype=request", extra, reqBlock.Bytes()); err != nil {
+		return err
+	}
+	return w.writeRecord("response", req.URL.String(), "application/http;msgtype=response", extra, respBlock.Bytes())
+}
+
+func (w *WARCWriter) writeRecord(recordType, targetURI, contentType string, extra [][2]string, block []byte) error {
+	fmt.Fprintf(w.w, "WARC/1.1\r\nWARC-Type: %s\r\nWARC-Record-ID: <urn:uuid:%s>\r\nWARC-Date: %s\r\n",
+		recordType, newUUID(), time.Now().UTC().Format(time.RFC3339))
+	if targetURI != "" {
+		fmt.Fprintf(w.w, "WARC-Target-URI: %s\r\n", targetURI)
+	}
+	for _, kv := range extra {
+		fmt.Fprintf(w.w, "%s: %s\r\n", kv[0], kv[1])
+	}
+	fmt.Fprintf(w.w, "Content-Type: %s\r\nContent-Length: %d\r\n\r\n", contentType, len(block))
+	w.w.Write(block)
+	_, err := w.w.WriteString("\r\n\r\n")
+	return err
+}
+
+func (w *WARCWriter) Close() error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.w.Flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// warcResponse is a recorded probe response read back by analyze.
+type warcResponse struct {
+	URL        string
+	Input      string
+	Param      string
+	Payload    string
+	StatusCode int
+	Body       string
+	Decoded    string
+}
+
+// readWARC returns the probe responses recorded in a WARC file, in order.
+// Records of other types, or without the probe fields, are skipped.
+func readWARC(path string) ([]warcResponse, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening WARC file: %w", err)
+	}
+	defer f.Close()
+
+	var responses []warcResponse
+	r := bufio.NewReader(f)
+	for {
+		version, err := r.ReadString('\n')
+		if err == io.EOF && strings.TrimSpace(version) == "" {
+			return responses, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading WARC file: %w", err)
+		}
+		if strings.TrimSpace(version) == "" {
+			continue
+		}
+		if !strings.HasPrefix(version, "WARC/") {
+			return nil, fmt.Errorf("reading WARC file: unexpected line %q", strings.TrimSpace(version))
+		}
+
+		fields := make(map[string]string)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return nil, fmt.Errorf("reading WARC record header: %w", err)
+			}
+			line = strings.TrimRight(line, "\r\n")
+			if line == "" {
+				break
+			}
+			if name, value, ok := strings.Cut(line, ":"); ok {
+				fields[strings.ToLower(name)] = strings.TrimSpace(value)
+			}
+		}
+		length, err := strconv.Atoi(fields["content-length"])
+		if err != nil {
+			return nil, fmt.Errorf("reading WARC record: invalid Content-Length %q", fields["content-length"])
+		}
+		block := make([]byte, length)
+		if _, err := io.ReadFull(r, block); err != nil {
+			return nil, fmt.Errorf("reading WARC record: %w", err)
+		}
+
+		payload, err := strconv.Unquote(fields[strings.ToLower(warcPayloadHeader)])
+		if fields["warc-type"] != "response" || err != nil {
+			continue
+		}
+		resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(block)), nil)
+		if err != nil {
+			return nil, fmt.Errorf("parsing recorded response for %s: %w", fields["warc-target-uri"], err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("parsing recorded response for %s: %w", fields["warc-target-uri"], err)
+		}
+		text := string(body)
+		var decoded string
+		if fields[strings.ToLower(warcDecodeJSONHeader)] == "true" {
+			decoded, _ = utils.JSONStrings(text)
+		}
+		responses = append(responses, warcResponse{
+			URL:        fields["warc-target-uri"],
+			Input:      fields[strings.ToLower(warcInputHeader)],
+			Param:      fields[strings.ToLower(warcParamHeader)],
+			Payload:    payload,
+			StatusCode: resp.StatusCode,
+			Body:       text,
+			Decoded:    decoded,
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Base64ParamPrefix marks targets whose payload is injected inside a
+// base64-encoded query value, e.g. b64:state or b64:state.user.name for a
+// field of an encoded JSON document.
+const Base64ParamPrefix = "b64:"
+
+// minBase64Len keeps short words, which often happen to be valid base64,
+// from being taken for encoded blobs.
+const minBase64Len = 8
+
+// base64Encodings are tried in order; the first that decodes a value is
+// used to re-encode it.
+var base64Encodings = []*base64.Encoding{
+	base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding,
+}
+
+// DecodeBase64Value returns the decoded text of a base64-encoded parameter
+// value and the encoding it uses. Only values that decode to printable
+// UTF-8 text count, so random tokens and plain words are left alone.
+func DecodeBase64Value(value string) (string, *base64.Encoding, bool) {
+	if len(value) < minBase64Len {
+		return "", nil, false
+	}
+	for _, enc := range base64Encodings {
+		decoded, err := enc.DecodeString(value)
+		if err != nil || !printableText(decoded) {
+			continue
+		}
+		return string(decoded), enc, true
+	}
+	return "", nil, false
+}
+
+func printableText(b []byte) bool {
+	if len(b) == 0 || !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonInjection is a JSON document with the payload in one string field.
+type jsonInjection struct {
+	path string
+	doc  string
+}
+
+// injectJSONStrings returns one copy of the JSON document data per string
+// field, sorted by field path, with that field set to payload. It reports
+// false when data is not a JSON object or array.
+func injectJSONStrings(data, payload string) ([]jsonInjection, bool) {
+	var v any
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		return nil, false
+	}
+	switch v.(type) {
+	case map[string]any, []any:
+	default:
+		return nil, false
+	}
+
+	var paths [][]string
+	collectStringPaths(v, nil, &paths)
+	var injections []jsonInjection
+	for _, path := range paths {
+		doc, err := marshalJSON(replaceAtPath(v, path, payload))
+		if err != nil {
+			continue
+		}
+		injections = append(injections, jsonInjection{path: strings.Join(path, "."), doc: doc})
+	}
+	sort.Slice(injections, func(i, j int) bool { return injections[i].path < injections[j].path })
+	return injections, true
+}
+
+// GenerateBase64Targets finds query parameters whose values are base64
+// blobs, as is common for state, return and data parameters, and injects
+// the payload inside: into every string field when the blob is JSON, and
+// appended to the text otherwise. The value is then re-encoded the way it
+// was, so the application decodes it successfully.
+func GenerateBase64Targets(inputURL, payload string) ([]Target, error) {
+	u, err := url.Parse(inputURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	parts := splitRawQuery(u.RawQuery)
+	seen := make(map[string]bool)
+	var targets []Target
+	for i, p := range parts {
+		if p.key == "" || seen[p.key] {
+			continue
+		}
+		seen[p.key] = true
+		value, err := url.QueryUnescape(p.value)
+		if err != nil {
+			continue
+		}
+		decoded, enc, ok := DecodeBase64Value(value)
+		if !ok {
+			continue
+		}
+		name, err := url.QueryUnescape(p.key)
+		if err != nil {
+			name = p.key
+		}
+
+		injections, isJSON := injectJSONStrings(decoded, payload)
+		if !isJSON {
+			injections = []jsonInjection{{doc: decoded + payload}}
+		}
+		for _, inj := range injections {
+			newParts := make([]queryPart, len(parts))
+			copy(newParts, parts)
+			newParts[i].value = url.QueryEscape(enc.EncodeToString([]byte(inj.doc)))
+
+			param := Base64ParamPrefix + name
+			if inj.path != "" {
+				param += "." + inj.path
+			}
+			newURL := *u
+			newURL.RawQuery = joinRawQuery(newParts)
+			targets = append(targets, Target{URL: newURL.String(), Param: param})
+		}
+	}
+	return targets, nil
+}
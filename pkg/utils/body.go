@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// BodyParamPrefix marks targets whose payload is carried in the request body.
+const BodyParamPrefix = "body:"
+
+// BodyContentType guesses the Content-Type for a --data body template.
+func BodyContentType(data string) string {
+	if boundary := multipartBoundary(data); boundary != "" {
+		return multipartContentType(boundary)
+	}
+	if isXMLBody(data) {
+		return xmlContentType(data)
+	}
+	trimmed := strings.TrimSpace(data)
+	if (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) &&
+		json.Valid([]byte(strings.ReplaceAll(trimmed, "{payload}", "x"))) {
+		return "application/json"
+	}
+	return "application/x-www-form-urlencoded"
+}
+
+// GenerateBodyTargets injects the payload into a request body template. A
+// {payload} placeholder is replaced directly; otherwise the body is parsed
+// as form data, as multipart/form-data when it starts with a boundary line,
+// as XML (element text and attribute values) when it starts with a tag, as
+// a GraphQL request (string variables), or as any other JSON document
+// (string fields, named by their path like user.name), and one target is
+// returned per field, mirroring how query parameters are
+// handled.
+func GenerateBodyTargets(inputURL, data, payload string) []Target {
+	if strings.Contains(data, "{payload}") {
+		return []Target{{
+			URL:   inputURL,
+			Param: BodyParamPrefix + PlaceholderParam,
+			Body:  strings.ReplaceAll(data, "{payload}", payload),
+		}}
+	}
+
+	if boundary := multipartBoundary(data); boundary != "" {
+		return generateMultipartTargets(inputURL, data, boundary, payload)
+	}
+	if isXMLBody(data) {
+		return generateXMLTargets(inputURL, data, payload)
+	}
+	if body, ok := parseGraphQLBody(data); ok {
+		return generateGraphQLTargets(inputURL, body, payload)
+	}
+	if BodyContentType(data) == "application/json" {
+		return generateJSONTargets(inputURL, data, payload)
+	}
+	if BodyContentType(data) != "application/x-www-form-urlencoded" {
+		return nil
+	}
+	values, err := url.ParseQuery(data)
+	if err != nil || len(values) == 0 {
+		return nil
+	}
+
+	var targets []Target
+	for key := range values {
+		newValues := url.Values{}
+		for k, v := range values {
+			if k == key {
+				newValues.Set(k, payload)
+			} else {
+				for _, val := range v {
+					newValues.Add(k, val)
+				}
+			}
+		}
+		targets = append(targets, Target{
+			URL:   inputURL,
+			Param: BodyParamPrefix + key,
+			Body:  newValues.Encode(),
+		})
+	}
+	return targets
+}
+
+// generateJSONTargets injects the payload into each string field of a JSON
+// body, keeping the document valid, the way JSON-valued query parameters
+// are expanded.
+func generateJSONTargets(inputURL, data, payload string) []Target {
+	injections, _ := injectJSONStrings(data, payload)
+	var targets []Target
+	for _, inj := range injections {
+		targets = append(targets, Target{
+			URL:        inputURL,
+			Param:      BodyParamPrefix + inj.path,
+			Body:       inj.doc,
+			DecodeJSON: true,
+		})
+	}
+	return targets
+}
@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// EncodeMode controls how payloads are encoded in query strings.
+type EncodeMode string
+
+const (
+	// EncodeAlways percent-encodes every special character (url.Values.Encode).
+	EncodeAlways EncodeMode = "always"
+	// EncodeAuto only encodes characters that would break the URL structure.
+	EncodeAuto EncodeMode = "auto"
+	// EncodeNever sends the payload exactly as given.
+	EncodeNever EncodeMode = "never"
+)
+
+// ParseEncodeMode validates an --encode-payload value.
+func ParseEncodeMode(s string) (EncodeMode, error) {
+	switch m := EncodeMode(strings.ToLower(s)); m {
+	case EncodeAlways, EncodeAuto, EncodeNever:
+		return m, nil
+	}
+	return "", fmt.Errorf("invalid encode mode %q (use never, auto or always)", s)
+}
+
+// payloadToken stands in for the payload while URLs are built so it can be
+// swapped for the payload encoded according to the chosen mode afterwards.
+const payloadToken = "XSSRECONPAYLOADTOKEN"
+
+// generateWithQueryEncoding builds targets with a placeholder token and then
+// substitutes the payload, encoded per mode, into query parameter values.
+func generateWithQueryEncoding(inputURL, payload string, mode EncodeMode) ([]Target, error) {
+	tokenTargets, err := generateTargetsWithEncoding(inputURL, payloadToken, EncodeAlways)
+	if err != nil {
+		return nil, err
+	}
+	encodedTargets, err := generateTargetsWithEncoding(inputURL, payload, EncodeAlways)
+	if err != nil {
+		return nil, err
+	}
+
+	byParam := make(map[string]Target, len(encodedTargets))
+	for _, t := range encodedTargets {
+		byParam[t.Param] = t
+	}
+
+	encoded := EncodeQueryValue(payload, mode)
+	for i, t := range tokenTargets {
+		u, err := url.Parse(t.URL)
+		if err != nil || !strings.Contains(u.RawQuery, payloadToken) {
+			// Not a query target; keep the normally encoded URL
+			tokenTargets[i] = byParam[t.Param]
+			continue
+		}
+		tokenTargets[i].URL = strings.Replace(t.URL, payloadToken, encoded, 1)
+	}
+	return tokenTargets, nil
+}
+
+// EncodeQueryValue encodes s for use as a query value according to mode.
+func EncodeQueryValue(s string, mode EncodeMode) string {
+	switch mode {
+	case EncodeNever:
+		return s
+	case EncodeAuto:
+		return escapeBytes(s, "#&%+;")
+	}
+	return url.QueryEscape(s)
+}
+
+// escapeBytes percent-encodes control characters, spaces, non-ASCII bytes
+// and the bytes in special, leaving everything else as is.
+func escapeBytes(s, special string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if c <= 0x20 || c >= 0x7f || strings.IndexByte(special, c) >= 0 {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// URLComponent is the part of a URL a payload is placed in.
+type URLComponent string
+
+const (
+	ComponentPath     URLComponent = "path"
+	ComponentQuery    URLComponent = "query"
+	ComponentFragment URLComponent = "fragment"
+)
+
+// EncodeURLComponent encodes s for use in component. Path payloads are
+// escaped as a single segment, since Go's HTTP client escapes anything
+// else in the path on the wire anyway and a raw / or ? would change which
+// resource is requested. Query payloads follow mode, and fragment payloads,
+// which only the browser sees, get just enough encoding to parse. Nothing
+// is encoded with EncodeNever.
+func EncodeURLComponent(s string, component URLComponent, mode EncodeMode) string {
+	if mode == EncodeNever {
+		return s
+	}
+	switch component {
+	case ComponentPath:
+		return url.PathEscape(s)
+	case ComponentFragment:
+		return escapeBytes(s, "%")
+	}
+	return EncodeQueryValue(s, mode)
+}
+
+// FillPlaceholders replaces every {payload} in inputURL with payload,
+// encoded for the URL component that placeholder is in. Target generation
+// substitutes placeholders verbatim; this is for callers asking otherwise.
+func FillPlaceholders(inputURL, payload string, mode EncodeMode) string {
+	var b strings.Builder
+	component := ComponentPath
+	rest := inputURL
+	for {
+		before, after, found := strings.Cut(rest, "{payload}")
+		if strings.Contains(before, "#") {
+			component = ComponentFragment
+		} else if component == ComponentPath && strings.Contains(before, "?") {
+			component = ComponentQuery
+		}
+		b.WriteString(before)
+		if !found {
+			return b.String()
+		}
+		b.WriteString(EncodeURLComponent(payload, component, mode))
+		rest = after
+	}
+}
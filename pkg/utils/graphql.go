@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GraphQLBody builds a GraphQL request body from a query and a JSON object
+// of variables. Either argument may be "@path" to read it from a file.
+func GraphQLBody(query, variables string) (string, error) {
+	query, err := readArgFile(query)
+	if err != nil {
+		return "", err
+	}
+	variables, err = readArgFile(variables)
+	if err != nil {
+		return "", err
+	}
+
+	body := map[string]any{"query": query}
+	if strings.TrimSpace(variables) != "" {
+		var vars map[string]any
+		dec := json.NewDecoder(strings.NewReader(variables))
+		dec.UseNumber()
+		if err := dec.Decode(&vars); err != nil {
+			return "", fmt.Errorf("invalid GraphQL variables: %w", err)
+		}
+		body["variables"] = vars
+	}
+	return marshalJSON(body)
+}
+
+func readArgFile(arg string) (string, error) {
+	if !strings.HasPrefix(arg, "@") {
+		return arg, nil
+	}
+	data, err := os.ReadFile(arg[1:])
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// parseGraphQLBody returns the decoded body when data is a GraphQL request
+// (a JSON object with a string "query" and an object "variables").
+func parseGraphQLBody(data string) (map[string]any, bool) {
+	var body map[string]any
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&body); err != nil {
+		return nil, false
+	}
+	_, hasQuery := body["query"].(string)
+	_, hasVars := body["variables"].(map[string]any)
+	return body, hasQuery && hasVars
+}
+
+// generateGraphQLTargets injects the payload into each string variable of a
+// GraphQL request, including strings nested in input objects and lists,
+// leaving the query itself untouched. Params are named after the variable
+// path, e.g. body:variables.input.name.
+func generateGraphQLTargets(inputURL string, body map[string]any, payload string) []Target {
+	var paths [][]string
+	collectStringPaths(body["variables"], []string{"variables"}, &paths)
+	sort.Slice(paths, func(i, j int) bool {
+		return strings.Join(paths[i], ".") < strings.Join(paths[j], ".")
+	})
+
+	var targets []Target
+	for _, path := range paths {
+		injected, ok := replaceAtPath(body, path, payload).(map[string]any)
+		if !ok {
+			continue
+		}
+		encoded, err := marshalJSON(injected)
+		if err != nil {
+			continue
+		}
+		targets = append(targets, Target{
+			URL:        inputURL,
+			Param:      BodyParamPrefix + strings.Join(path, "."),
+			Body:       encoded,
+			DecodeJSON: true,
+		})
+	}
+	return targets
+}
+
+func collectStringPaths(v any, path []string, paths *[][]string) {
+	switch v := v.(type) {
+	case string:
+		*paths = append(*paths, append([]string{}, path...))
+	case map[string]any:
+		for k, child := range v {
+			collectStringPaths(child, append(path, k), paths)
+		}
+	case []any:
+		for i, child := range v {
+			collectStringPaths(child, append(path, strconv.Itoa(i)), paths)
+		}
+	}
+}
+
+// replaceAtPath returns a copy of v with the value at path set to payload.
+func replaceAtPath(v any, path []string, payload string) any {
+	if len(path) == 0 {
+		return payload
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		c := make(map[string]any, len(v))
+		for k, child := range v {
+			c[k] = child
+		}
+		c[path[0]] = replaceAtPath(v[path[0]], path[1:], payload)
+		return c
+	case []any:
+		c := append([]any{}, v...)
+		if i, err := strconv.Atoi(path[0]); err == nil && i < len(c) {
+			c[i] = replaceAtPath(c[i], path[1:], payload)
+		}
+		return c
+	}
+	return v
+}
+
+// marshalJSON encodes v without escaping <, > and &, so special characters
+// are sent as-is.
+func marshalJSON(v any) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// JSONStrings returns every string value in a JSON document, decoded, one
+// per line. It lets reflections inside API responses be matched as the
+// consuming page would see them rather than JSON-escaped.
+func JSONStrings(data string) (string, bool) {
+	var v any
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		return "", false
+	}
+	var b strings.Builder
+	var walk func(any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case string:
+			b.WriteString(v)
+			b.WriteByte('\n')
+		case map[string]any:
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(v)
+	return b.String(), true
+}
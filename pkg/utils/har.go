@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+type harFile struct {
+	Log struct {
+		Entries []struct {
+			Request harRequest `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+type harRequest struct {
+	Method  string `json:"method"`
+	URL     string `json:"url"`
+	Headers []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"headers"`
+	PostData *struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+	} `json:"postData"`
+}
+
+// LoadHAR reads a HAR capture and returns every GET or POST request that has
+// query parameters or a body, skipping duplicates.
+func LoadHAR(path string) ([]*Request, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening HAR file: %w", err)
+	}
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("parsing HAR file: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var requests []*Request
+	for _, entry := range har.Log.Entries {
+		hr := entry.Request
+		method := strings.ToUpper(hr.Method)
+		if method != http.MethodGet && method != http.MethodPost {
+			continue
+		}
+		u, err := url.Parse(hr.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		// Fragments never reach the server
+		u.Fragment = ""
+
+		req := &Request{
+			Method:  method,
+			URL:     u.String(),
+			Headers: make(map[string]string),
+		}
+		if hr.PostData != nil {
+			req.Body = hr.PostData.Text
+		}
+		if u.RawQuery == "" && req.Body == "" {
+			continue
+		}
+
+		key := req.Method + " " + req.URL + "\n" + req.Body
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		for _, h := range hr.Headers {
+			name := http.CanonicalHeaderKey(h.Name)
+			// HTTP/2 pseudo-headers such as :authority are not real headers
+			if strings.HasPrefix(h.Name, ":") {
+				continue
+			}
+			if name == "Cookie" {
+				req.Cookie = h.Value
+				continue
+			}
+			if skippedRawHeaders[name] {
+				continue
+			}
+			req.Headers[name] = h.Value
+		}
+		requests = append(requests, req)
+	}
+	return requests, nil
+}
@@ -0,0 +1,38 @@
+package utils
+
+import "net/http"
+
+// HeaderParamPrefix marks targets whose payload is carried in a request header.
+const HeaderParamPrefix = "header:"
+
+// GenerateHeaderTargets returns one target per header name, each sending the
+// unmodified input URL with the payload as that header's value.
+func GenerateHeaderTargets(inputURL string, headers []string, payload string) []Target {
+	targets := make([]Target, 0, len(headers))
+	for _, h := range headers {
+		name := http.CanonicalHeaderKey(h)
+		targets = append(targets, Target{
+			URL:     inputURL,
+			Param:   HeaderParamPrefix + name,
+			Headers: map[string]string{name: payload},
+		})
+	}
+	return targets
+}
+
+// CookieParamPrefix marks targets whose payload replaces a cookie value.
+const CookieParamPrefix = "cookie:"
+
+// GenerateCookieTargets returns one target per cookie name, each sending the
+// unmodified input URL with that cookie's value replaced by the payload.
+func GenerateCookieTargets(inputURL string, names []string, payload string) []Target {
+	targets := make([]Target, 0, len(names))
+	for _, name := range names {
+		targets = append(targets, Target{
+			URL:     inputURL,
+			Param:   CookieParamPrefix + name,
+			Cookies: map[string]string{name: payload},
+		})
+	}
+	return targets
+}
@@ -0,0 +1,27 @@
+package utils
+
+// InterleaveByHost reorders reqs so that consecutive requests go to
+// different hosts: one request from each host in turn, hosts in the order
+// they first appear. Each host's requests keep their relative order.
+func InterleaveByHost(reqs []*Request) []*Request {
+	queues := make(map[string][]*Request)
+	var hosts []string
+	for _, req := range reqs {
+		host := sampleGroupKey(req.URL, true)
+		if _, ok := queues[host]; !ok {
+			hosts = append(hosts, host)
+		}
+		queues[host] = append(queues[host], req)
+	}
+
+	out := make([]*Request, 0, len(reqs))
+	for len(out) < len(reqs) {
+		for _, host := range hosts {
+			if q := queues[host]; len(q) > 0 {
+				out = append(out, q[0])
+				queues[host] = q[1:]
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+)
+
+// MultipartFilenameSuffix marks multipart targets whose payload is carried
+// in the filename of a file part rather than in the part's content.
+const MultipartFilenameSuffix = ".filename"
+
+// multipartBoundary returns the boundary of a multipart/form-data body, taken
+// from its first delimiter line, or "" if data is not multipart.
+func multipartBoundary(data string) string {
+	trimmed := strings.TrimLeft(data, "\r\n")
+	if !strings.HasPrefix(trimmed, "--") {
+		return ""
+	}
+	line, _, _ := strings.Cut(trimmed, "\n")
+	boundary := strings.TrimSuffix(strings.TrimPrefix(line, "--"), "\r")
+	if boundary == "" || strings.ContainsAny(boundary, " \t") ||
+		!strings.Contains(data, "--"+boundary+"--") {
+		return ""
+	}
+	return boundary
+}
+
+// multipartPart is one part of a parsed multipart body.
+type multipartPart struct {
+	header   textproto.MIMEHeader
+	name     string
+	filename string
+	content  []byte
+}
+
+func parseMultipart(data, boundary string) ([]multipartPart, error) {
+	// The closing delimiter must end its line, with the same line ending as
+	// the first one; shells and editors often leave a stray CR or none at all
+	newline := "\n"
+	if line, _, _ := strings.Cut(data, "\n"); strings.HasSuffix(line, "\r") {
+		newline = "\r\n"
+	}
+	data = strings.TrimRight(data, "\r\n") + newline
+	mr := multipart.NewReader(strings.NewReader(data), boundary)
+	var parts []multipartPart
+	for {
+		p, err := mr.NextRawPart()
+		if err == io.EOF {
+			return parts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(p)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, multipartPart{
+			header:   p.Header,
+			name:     p.FormName(),
+			filename: p.FileName(),
+			content:  content,
+		})
+	}
+}
+
+// generateMultipartTargets injects the payload into each text field of a
+// multipart body and into the filename of each file part, leaving file
+// contents untouched.
+func generateMultipartTargets(inputURL, data, boundary, payload string) []Target {
+	parts, err := parseMultipart(data, boundary)
+	if err != nil {
+		return nil
+	}
+
+	var targets []Target
+	for i, p := range parts {
+		if p.name == "" {
+			continue
+		}
+		param := BodyParamPrefix + p.name
+		if p.filename != "" {
+			param += MultipartFilenameSuffix
+		}
+		targets = append(targets, Target{
+			URL:   inputURL,
+			Param: param,
+			Body:  buildMultipart(parts, boundary, i, payload),
+		})
+	}
+	return targets
+}
+
+// buildMultipart serializes parts, replacing the content of part inject (or
+// its filename, for file parts) with payload. The payload is written
+// verbatim, without the quoting mime.FormatMediaType would apply, so quotes
+// and other special characters reach the server as sent.
+func buildMultipart(parts []multipartPart, boundary string, inject int, payload string) string {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	w.SetBoundary(boundary)
+	for i, p := range parts {
+		header := p.header
+		content := p.content
+		if i == inject {
+			header = cloneHeader(p.header)
+			if p.filename != "" {
+				header.Set("Content-Disposition", `form-data; name="`+p.name+`"; filename="`+payload+`"`)
+			} else {
+				content = []byte(payload)
+			}
+		}
+		pw, err := w.CreatePart(header)
+		if err != nil {
+			return ""
+		}
+		pw.Write(content)
+	}
+	w.Close()
+	return buf.String()
+}
+
+func cloneHeader(h textproto.MIMEHeader) textproto.MIMEHeader {
+	c := make(textproto.MIMEHeader, len(h))
+	for k, v := range h {
+		c[k] = append([]string(nil), v...)
+	}
+	return c
+}
+
+// multipartContentType returns the Content-Type for a multipart body with
+// the given boundary.
+func multipartContentType(boundary string) string {
+	return mime.FormatMediaType("multipart/form-data", map[string]string{"boundary": boundary})
+}
@@ -0,0 +1,317 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// MatrixParamPrefix marks targets whose payload replaces a matrix parameter
+// in the path (e.g. /products;color=red).
+const MatrixParamPrefix = "matrix:"
+
+// queryPart is one key[=value] pair of a raw query string together with the
+// separator that preceded it.
+type queryPart struct {
+	sep      string
+	key      string
+	value    string
+	hasValue bool
+}
+
+// hasNonStandardQuery reports whether rawQuery uses ';' separators or keys
+// without '=', both of which url.Values drops or rewrites.
+func hasNonStandardQuery(rawQuery string) bool {
+	if strings.Contains(rawQuery, ";") {
+		return true
+	}
+	for _, part := range strings.Split(rawQuery, "&") {
+		if part != "" && !strings.Contains(part, "=") {
+			return true
+		}
+	}
+	return false
+}
+
+func splitRawQuery(rawQuery string) []queryPart {
+	var parts []queryPart
+	sep := ""
+	for rawQuery != "" {
+		i := strings.IndexAny(rawQuery, "&;")
+		chunk := rawQuery
+		next := ""
+		if i >= 0 {
+			chunk, next = rawQuery[:i], rawQuery[i:i+1]
+			rawQuery = rawQuery[i+1:]
+		} else {
+			rawQuery = ""
+		}
+
+		p := queryPart{sep: sep, key: chunk}
+		if k, v, ok := strings.Cut(chunk, "="); ok {
+			p.key, p.value, p.hasValue = k, v, true
+		}
+		parts = append(parts, p)
+		sep = next
+	}
+	return parts
+}
+
+func joinRawQuery(parts []queryPart) string {
+	var b strings.Builder
+	for _, p := range parts {
+		b.WriteString(p.sep)
+		b.WriteString(p.key)
+		if p.hasValue {
+			b.WriteString("=")
+			b.WriteString(p.value)
+		}
+	}
+	return b.String()
+}
+
+// generateRawQueryTargets rebuilds the query by hand, keeping the original
+// separators and the encoding of untouched parameters. Keys without '='
+// receive the payload as their value.
+func generateRawQueryTargets(u *url.URL, payload string) []Target {
+	parts := splitRawQuery(u.RawQuery)
+	seen := make(map[string]bool)
+	var targets []Target
+	for _, p := range parts {
+		if p.key == "" || seen[p.key] {
+			continue
+		}
+		seen[p.key] = true
+
+		newParts := make([]queryPart, len(parts))
+		copy(newParts, parts)
+		for i := range newParts {
+			if newParts[i].key == p.key {
+				newParts[i].value = url.QueryEscape(payload)
+				newParts[i].hasValue = true
+			}
+		}
+
+		name, err := url.QueryUnescape(p.key)
+		if err != nil {
+			name = p.key
+		}
+		newURL := *u
+		newURL.RawQuery = joinRawQuery(newParts)
+		targets = append(targets, Target{URL: newURL.String(), Param: name})
+	}
+	return targets
+}
+
+// generateMatrixTargets returns one target per matrix parameter found in
+// the path, as used by Java and other legacy frameworks
+// (/cart;jsessionid=abc/items;color=red).
+func generateMatrixTargets(u *url.URL, payload string) []Target {
+	escaped := u.EscapedPath()
+	if !strings.Contains(escaped, ";") {
+		return nil
+	}
+
+	segments := strings.Split(escaped, "/")
+	var targets []Target
+	for si, seg := range segments {
+		params := strings.Split(seg, ";")
+		for pi := 1; pi < len(params); pi++ {
+			key, _, _ := strings.Cut(params[pi], "=")
+			if key == "" {
+				continue
+			}
+
+			newParams := make([]string, len(params))
+			copy(newParams, params)
+			newParams[pi] = key + "=" + url.PathEscape(payload)
+			newSegments := make([]string, len(segments))
+			copy(newSegments, segments)
+			newSegments[si] = strings.Join(newParams, ";")
+
+			rawPath := strings.Join(newSegments, "/")
+			path, err := url.PathUnescape(rawPath)
+			if err != nil {
+				continue
+			}
+			newURL := *u
+			newURL.Path = path
+			newURL.RawPath = rawPath
+			targets = append(targets, Target{URL: newURL.String(), Param: MatrixParamPrefix + key})
+		}
+	}
+	return targets
+}
+
+// GenerateTargetsKeepingValue is like GenerateTargetsWithEncoding but
+// appends the payload to each query parameter's original value
+// (q=shoes<payload>) instead of replacing it. Other injection points are
+// replaced as usual.
+func GenerateTargetsKeepingValue(inputURL, payload string, mode EncodeMode) ([]Target, error) {
+	targets, err := GenerateTargetsWithEncoding(inputURL, payload, mode)
+	if err != nil || strings.Contains(inputURL, "{payload}") {
+		return targets, err
+	}
+	u, err := url.Parse(inputURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	original := firstQueryValues(u)
+	for i, t := range targets {
+		value, ok := original[t.Param]
+		if !ok || value == "" {
+			continue
+		}
+		kept, err := GenerateTargetsWithEncoding(inputURL, value+payload, mode)
+		if err != nil {
+			continue
+		}
+		for _, k := range kept {
+			if k.Param == t.Param {
+				targets[i].URL = k.URL
+				break
+			}
+		}
+	}
+	return targets, nil
+}
+
+// FirstQueryValues returns the decoded first value of each query parameter
+// of inputURL.
+func FirstQueryValues(inputURL string) (map[string]string, error) {
+	u, err := url.Parse(inputURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	return firstQueryValues(u), nil
+}
+
+// firstQueryValues returns the decoded first value of each query parameter.
+func firstQueryValues(u *url.URL) map[string]string {
+	values := make(map[string]string)
+	for _, p := range splitRawQuery(u.RawQuery) {
+		key, err := url.QueryUnescape(p.key)
+		if err != nil {
+			key = p.key
+		}
+		value, err := url.QueryUnescape(p.value)
+		if err != nil {
+			value = p.value
+		}
+		if _, ok := values[key]; !ok {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// expandJSONQueryTargets replaces the target of each query parameter whose
+// value is a JSON object or array (?filter={"name":"x"}) with one target per
+// string field, named like filter.name, that keeps the document valid and
+// only swaps that field for the payload. Replacing the whole blob usually
+// just triggers a server-side parse error.
+func expandJSONQueryTargets(inputURL string, targets []Target, payload string, mode EncodeMode) []Target {
+	u, err := url.Parse(inputURL)
+	if err != nil {
+		return targets
+	}
+	original := firstQueryValues(u)
+
+	var expanded []Target
+	for _, t := range targets {
+		value, ok := original[t.Param]
+		if !ok {
+			expanded = append(expanded, t)
+			continue
+		}
+		injections, isJSON := injectJSONStrings(value, payload)
+		if !isJSON || len(injections) == 0 {
+			expanded = append(expanded, t)
+			continue
+		}
+		for _, inj := range injections {
+			fieldTargets, err := generateTargetsWithEncoding(inputURL, inj.doc, mode)
+			if err != nil {
+				continue
+			}
+			for _, ft := range fieldTargets {
+				if ft.Param == t.Param {
+					expanded = append(expanded, Target{URL: ft.URL, Param: t.Param + "." + inj.path})
+					break
+				}
+			}
+		}
+	}
+	return expanded
+}
+
+// NameParamPrefix marks targets whose payload replaces a query parameter
+// name rather than its value (?<payload>=1&id=2).
+const NameParamPrefix = "name:"
+
+// GenerateParamNameTargets returns one target per query parameter with its
+// name replaced by the payload and its value kept, plus one that appends
+// <payload>=1 as an extra parameter, for pages that echo unknown
+// parameter names. The appended target's Param is NameParamPrefix alone.
+func GenerateParamNameTargets(inputURL, payload string) ([]Target, error) {
+	u, err := url.Parse(inputURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	parts := splitRawQuery(u.RawQuery)
+	seen := make(map[string]bool)
+	var targets []Target
+	for i, p := range parts {
+		if p.key == "" || seen[p.key] {
+			continue
+		}
+		seen[p.key] = true
+
+		newParts := make([]queryPart, len(parts))
+		copy(newParts, parts)
+		newParts[i].key = url.QueryEscape(payload)
+
+		name, err := url.QueryUnescape(p.key)
+		if err != nil {
+			name = p.key
+		}
+		newURL := *u
+		newURL.RawQuery = joinRawQuery(newParts)
+		targets = append(targets, Target{URL: newURL.String(), Param: NameParamPrefix + name})
+	}
+
+	extra := url.QueryEscape(payload) + "=1"
+	newURL := *u
+	if u.RawQuery == "" {
+		newURL.RawQuery = extra
+	} else {
+		newURL.RawQuery = u.RawQuery + "&" + extra
+	}
+	targets = append(targets, Target{URL: newURL.String(), Param: NameParamPrefix})
+	return targets, nil
+}
+
+// SetQueryValues returns inputURL with the query parameters named in values
+// set to the given values, all at once, keeping the order, separators and
+// encoding of the rest of the query.
+func SetQueryValues(inputURL string, values map[string]string) (string, error) {
+	u, err := url.Parse(inputURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+	parts := splitRawQuery(u.RawQuery)
+	for i, p := range parts {
+		name, err := url.QueryUnescape(p.key)
+		if err != nil {
+			name = p.key
+		}
+		if v, ok := values[name]; ok {
+			parts[i].value = url.QueryEscape(v)
+			parts[i].hasValue = true
+		}
+	}
+	u.RawQuery = joinRawQuery(parts)
+	return u.String(), nil
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Request is a request template to scan. Only URL is required; the other
+// fields are set when the target comes from a raw request file and override
+// the scanner-wide method, body, headers and cookies.
+type Request struct {
+	Method  string
+	URL     string
+	Headers map[string]string
+	Cookie  string
+	Body    string
+	// InjectHeaders lists the headers worth probing as injection points.
+	InjectHeaders []string
+}
+
+// skippedRawHeaders are managed by the HTTP client or handled separately and
+// must not be replayed verbatim.
+var skippedRawHeaders = map[string]bool{
+	"Host":              true,
+	"Content-Length":    true,
+	"Cookie":            true,
+	"Connection":        true,
+	"Accept-Encoding":   true,
+	"Transfer-Encoding": true,
+}
+
+// injectableRawHeaders are standard headers that commonly get reflected.
+var injectableRawHeaders = map[string]bool{
+	"User-Agent":       true,
+	"Referer":          true,
+	"Origin":           true,
+	"X-Forwarded-For":  true,
+	"X-Forwarded-Host": true,
+}
+
+// LoadRawRequest reads and parses a raw HTTP request file, such as one
+// exported from Burp Suite.
+func LoadRawRequest(path, scheme string) (*Request, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening request file: %w", err)
+	}
+	defer f.Close()
+	return ParseRawRequest(f, scheme)
+}
+
+// ParseRawRequest parses a raw HTTP/1.x request. The target URL is built
+// from the Host header and request line using scheme.
+func ParseRawRequest(r io.Reader, scheme string) (*Request, error) {
+	req, err := http.ReadRequest(bufio.NewReader(r))
+	if err != nil {
+		return nil, fmt.Errorf("parsing request file: %w", err)
+	}
+	defer req.Body.Close()
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading request body: %w", err)
+	}
+	if req.Host == "" {
+		return nil, fmt.Errorf("parsing request file: missing Host header")
+	}
+
+	u := &url.URL{
+		Scheme:   scheme,
+		Host:     req.Host,
+		Path:     req.URL.Path,
+		RawPath:  req.URL.RawPath,
+		RawQuery: req.URL.RawQuery,
+	}
+
+	raw := &Request{
+		Method:  req.Method,
+		URL:     u.String(),
+		Headers: make(map[string]string),
+		Cookie:  req.Header.Get("Cookie"),
+		Body:    string(body),
+	}
+	for name, values := range req.Header {
+		if skippedRawHeaders[name] || len(values) == 0 {
+			continue
+		}
+		raw.Headers[name] = values[0]
+		if injectableRawHeaders[name] || strings.HasPrefix(name, "X-") {
+			raw.InjectHeaders = append(raw.InjectHeaders, name)
+		}
+	}
+	return raw, nil
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// SampleSpec describes how to sample a target list, either a percentage of
+// every host/path group or a fixed number of targets per host.
+type SampleSpec struct {
+	Percent float64
+	PerHost int
+}
+
+// ParseSample parses a --sample value such as "10%" or "5".
+func ParseSample(spec string) (SampleSpec, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasSuffix(spec, "%") {
+		pct, err := strconv.ParseFloat(strings.TrimSuffix(spec, "%"), 64)
+		if err != nil || pct <= 0 || pct > 100 {
+			return SampleSpec{}, fmt.Errorf("invalid sample percentage %q", spec)
+		}
+		return SampleSpec{Percent: pct}, nil
+	}
+	n, err := strconv.Atoi(spec)
+	if err != nil || n <= 0 {
+		return SampleSpec{}, fmt.Errorf("invalid sample size %q (use N or N%%)", spec)
+	}
+	return SampleSpec{PerHost: n}, nil
+}
+
+// SampleResult holds the sampled targets along with coverage statistics.
+type SampleResult struct {
+	Targets []string
+	Total   int
+	Groups  int
+}
+
+// Coverage returns the sampled fraction of the input as a percentage.
+func (r SampleResult) Coverage() float64 {
+	if r.Total == 0 {
+		return 0
+	}
+	return float64(len(r.Targets)) / float64(r.Total) * 100
+}
+
+// SampleTargets randomly picks targets from each group. Percentage samples
+// group by host and path, fixed-size samples group by host. Every group keeps
+// at least one target so no part of the scope is skipped entirely.
+func SampleTargets(targets []string, spec SampleSpec) SampleResult {
+	groups := make(map[string][]string)
+	var order []string
+	for _, t := range targets {
+		key := sampleGroupKey(t, spec.PerHost > 0)
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], t)
+	}
+
+	result := SampleResult{Total: len(targets), Groups: len(groups)}
+	for _, key := range order {
+		group := groups[key]
+		n := spec.PerHost
+		if spec.Percent > 0 {
+			n = int(math.Ceil(float64(len(group)) * spec.Percent / 100))
+		}
+		if n > len(group) {
+			n = len(group)
+		}
+		rand.Shuffle(len(group), func(i, j int) { group[i], group[j] = group[j], group[i] })
+		result.Targets = append(result.Targets, group[:n]...)
+	}
+	return result
+}
+
+func sampleGroupKey(target string, hostOnly bool) string {
+	u, err := url.Parse(target)
+	if err != nil {
+		return target
+	}
+	if hostOnly {
+		return u.Host
+	}
+	return u.Host + u.Path
+}
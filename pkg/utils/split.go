@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"cmp"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// SplitTargets shards targets into n lists for distributed runs. All targets
+// of a host go to the same shard so per-host rate limits still hold; hosts
+// are placed largest first on the currently smallest shard, with ties
+// broken by a hash of the host so the result is stable across runs.
+func SplitTargets(targets []string, n int) [][]string {
+	groups := make(map[string][]string)
+	var hosts []string
+	for _, t := range targets {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		host := sampleGroupKey(t, true)
+		if _, ok := groups[host]; !ok {
+			hosts = append(hosts, host)
+		}
+		groups[host] = append(groups[host], t)
+	}
+	slices.SortFunc(hosts, func(a, b string) int {
+		if c := cmp.Compare(len(groups[b]), len(groups[a])); c != 0 {
+			return c
+		}
+		return cmp.Compare(hostHash(a), hostHash(b))
+	})
+
+	shards := make([][]string, n)
+	for _, host := range hosts {
+		smallest := 0
+		for i := range shards {
+			if len(shards[i]) < len(shards[smallest]) {
+				smallest = i
+			}
+		}
+		shards[smallest] = append(shards[smallest], groups[host]...)
+	}
+	return shards
+}
+
+func hostHash(host string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(host))
+	return h.Sum32()
+}
+
+// ShardName numbers a shard file after path: urls.txt becomes urls.03.txt
+// for shard 3 of 10.
+func ShardName(path string, shard, total int) string {
+	ext := filepath.Ext(path)
+	width := len(fmt.Sprint(total))
+	return fmt.Sprintf("%s.%0*d%s", strings.TrimSuffix(path, ext), width, shard, ext)
+}
+
+// WriteShards writes each shard next to path and returns the file names.
+func WriteShards(path string, shards [][]string) ([]string, error) {
+	names := make([]string, len(shards))
+	for i, shard := range shards {
+		names[i] = ShardName(path, i+1, len(shards))
+		var b strings.Builder
+		for _, t := range shard {
+			b.WriteString(t)
+			b.WriteByte('\n')
+		}
+		if err := os.WriteFile(names[i], []byte(b.String()), 0o644); err != nil {
+			return nil, fmt.Errorf("writing shard: %w", err)
+		}
+	}
+	return names, nil
+}
@@ -1,55 +1,189 @@
-package utils
-
-import (
-	"fmt"
-	"net/url"
-	"strings"
-)
-
-// GenerateTargetURLs replaces injection points in the input URL with the payload.
-// It mimics the behavior of pvreplace.
-func GenerateTargetURLs(inputURL, payload string) ([]string, error) {
-	var targets []string
-
-	// Case 1: URL has {payload} placeholder
-	if strings.Contains(inputURL, "{payload}") {
-		target := strings.ReplaceAll(inputURL, "{payload}", payload)
-		targets = append(targets, target)
-		return targets, nil
-	}
-
-	// Case 2: URL has query parameters
-	u, err := url.Parse(inputURL)
-	if err != nil {
-		return nil, fmt.Errorf("invalid URL: %w", err)
-	}
-
-	queryParams := u.Query()
-	if len(queryParams) == 0 {
-		return nil, fmt.Errorf("no injection points found")
-	}
-
-	// Create a target for each parameter being replaced
-	for key := range queryParams {
-		// Create a copy of the query params
-		newParams := url.Values{}
-		for k, v := range queryParams {
-			if k == key {
-				newParams.Set(k, payload)
-			} else {
-				// Keep other params as is (using the first value if multiple exist, or join them? pvreplace usually replaces value)
-				// For simplicity and standard behavior, we keep the original values.
-				for _, val := range v {
-					newParams.Add(k, val)
-				}
-			}
-		}
-		
-		// Reconstruct the URL
-		newURL := *u
-		newURL.RawQuery = newParams.Encode()
-		targets = append(targets, newURL.String())
-	}
-
-	return targets, nil
-}
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrNoInjectionPoints is returned when a URL has neither a {payload}
+// placeholder nor query parameters to replace.
+var ErrNoInjectionPoints = errors.New("no injection points found")
+
+// PlaceholderParam is the parameter name reported for targets built from a
+// {payload} placeholder rather than from a query parameter.
+const PlaceholderParam = "{payload}"
+
+// Target is a single generated request together with the injection point it
+// covers. Headers and Cookies hold extra request headers and cookie value
+// overrides, used when the payload is carried outside the URL. Method and
+// Body are empty for plain GET requests.
+type Target struct {
+	URL     string
+	Param   string
+	Method  string
+	Body    string
+	Headers map[string]string
+	Cookies map[string]string
+	// DecodeJSON asks for reflections to also be matched against the decoded
+	// string values of a JSON response, as for GraphQL APIs.
+	DecodeJSON bool
+	// Input is the URL of the request template the target was generated
+	// from and Payload the value injected into it.
+	Input   string
+	Payload string
+}
+
+// GenerateTargetURLs replaces injection points in the input URL with the payload.
+// It mimics the behavior of pvreplace.
+func GenerateTargetURLs(inputURL, payload string) ([]string, error) {
+	targets, err := GenerateTargets(inputURL, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]string, 0, len(targets))
+	for _, t := range targets {
+		urls = append(urls, t.URL)
+	}
+	return urls, nil
+}
+
+// GenerateTargets is like GenerateTargetURLs but also records which
+// injection point each generated URL covers.
+func GenerateTargets(inputURL, payload string) ([]Target, error) {
+	return GenerateTargetsWithEncoding(inputURL, payload, EncodeAlways)
+}
+
+// GenerateTargetsWithEncoding is like GenerateTargets but controls how the
+// payload is encoded when it replaces a query parameter value.
+func GenerateTargetsWithEncoding(inputURL, payload string, mode EncodeMode) ([]Target, error) {
+	targets, err := generateTargetsWithEncoding(inputURL, payload, mode)
+	if err != nil || strings.Contains(inputURL, "{payload}") {
+		return targets, err
+	}
+	return expandJSONQueryTargets(inputURL, targets, payload, mode), nil
+}
+
+func generateTargetsWithEncoding(inputURL, payload string, mode EncodeMode) ([]Target, error) {
+	var targets []Target
+
+	// Case 1: URL has {payload} placeholder
+	if strings.Contains(inputURL, "{payload}") {
+		target := strings.ReplaceAll(inputURL, "{payload}", payload)
+		targets = append(targets, Target{URL: target, Param: PlaceholderParam})
+		return targets, nil
+	}
+
+	if mode != EncodeAlways {
+		return generateWithQueryEncoding(inputURL, payload, mode)
+	}
+
+	// Case 2: URL has query parameters
+	u, err := url.Parse(inputURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	// Semicolon separators and keys without '=' are dropped or rewritten
+	// by url.Values, so such queries are rebuilt by hand
+	queryParams := u.Query()
+	if hasNonStandardQuery(u.RawQuery) {
+		targets = append(targets, generateRawQueryTargets(u, payload)...)
+		queryParams = nil
+	}
+
+	// Create a target for each parameter being replaced
+	for key := range queryParams {
+		// Create a copy of the query params
+		newParams := url.Values{}
+		for k, v := range queryParams {
+			if k == key {
+				newParams.Set(k, payload)
+			} else {
+				// Keep other params as is (using the first value if multiple exist, or join them? pvreplace usually replaces value)
+				// For simplicity and standard behavior, we keep the original values.
+				for _, val := range v {
+					newParams.Add(k, val)
+				}
+			}
+		}
+		
+		// Reconstruct the URL
+		newURL := *u
+		newURL.RawQuery = newParams.Encode()
+		targets = append(targets, Target{URL: newURL.String(), Param: key})
+	}
+
+	// Case 3: path has matrix parameters
+	targets = append(targets, generateMatrixTargets(u, payload)...)
+
+	if len(targets) == 0 {
+		return nil, ErrNoInjectionPoints
+	}
+	return targets, nil
+}
+
+// MatchParam reports whether the injection point param is one of names.
+// Names match either the full param (header:Referer, body:user.name) or the
+// name behind any source prefix, so "q" selects both the query parameter q
+// and the body field body:q. A name also selects the fields of a JSON value
+// it holds, so "filter" selects filter.name.
+func MatchParam(param string, names []string) bool {
+	bare := param
+	for _, prefix := range []string{BodyParamPrefix, HeaderParamPrefix, CookieParamPrefix, MatrixParamPrefix, PathParamPrefix, NameParamPrefix, Base64ParamPrefix} {
+		if strings.HasPrefix(param, prefix) {
+			bare = strings.TrimPrefix(param, prefix)
+			break
+		}
+	}
+	for _, name := range names {
+		if name == param || name == bare {
+			return true
+		}
+		if root, _, ok := strings.Cut(bare, "."); ok && name == root {
+			return true
+		}
+	}
+	return false
+}
+
+// PathParamPrefix marks targets whose payload replaces a path segment.
+const PathParamPrefix = "path:"
+
+// GeneratePathTargets returns one target per non-empty path segment of the
+// input URL, with that segment replaced by the payload
+// (e.g. /blog/<payload>/view). Segments are numbered from 1 in Param. The
+// payload is escaped as a segment so a / or ? in it stays part of it.
+func GeneratePathTargets(inputURL, payload string) ([]Target, error) {
+	u, err := url.Parse(inputURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	segments := strings.Split(u.EscapedPath(), "/")
+	var targets []Target
+	n := 0
+	for i, seg := range segments {
+		if seg == "" {
+			continue
+		}
+		n++
+
+		newSegments := make([]string, len(segments))
+		copy(newSegments, segments)
+		newSegments[i] = EncodeURLComponent(payload, ComponentPath, EncodeAlways)
+
+		rawPath := strings.Join(newSegments, "/")
+		path, err := url.PathUnescape(rawPath)
+		if err != nil {
+			continue
+		}
+		newURL := *u
+		newURL.Path = path
+		newURL.RawPath = rawPath
+		targets = append(targets, Target{URL: newURL.String(), Param: fmt.Sprintf("%s%d", PathParamPrefix, n)})
+	}
+	return targets, nil
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/xml"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// isXMLBody reports whether a body template is an XML document.
+func isXMLBody(data string) bool {
+	return strings.HasPrefix(strings.TrimSpace(data), "<")
+}
+
+// xmlContentType picks text/xml for SOAP 1.1 envelopes, which servers
+// expect, and application/xml for everything else.
+func xmlContentType(data string) string {
+	if strings.Contains(data, "schemas.xmlsoap.org/soap/envelope") {
+		return "text/xml; charset=utf-8"
+	}
+	return "application/xml"
+}
+
+var (
+	xmlTagNameRe = regexp.MustCompile(`^<([\w:.-]+)`)
+	xmlAttrRe    = regexp.MustCompile(`([\w:.-]+)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+)
+
+// xmlInjectionPoint is a byte range of an XML template that holds element
+// text or an attribute value.
+type xmlInjectionPoint struct {
+	name       string
+	start, end int
+}
+
+// xmlInjectionPoints lists the element text and attribute values of an XML
+// template in document order. Comments, CDATA sections, processing
+// instructions, doctypes and namespace declarations are left alone.
+func xmlInjectionPoints(data string) []xmlInjectionPoint {
+	var points []xmlInjectionPoint
+	var element string
+	for i := 0; i < len(data); {
+		if data[i] != '<' {
+			end := strings.IndexByte(data[i:], '<')
+			if end == -1 {
+				end = len(data) - i
+			}
+			text := data[i : i+end]
+			if trimmed := strings.TrimSpace(text); trimmed != "" && element != "" {
+				start := i + strings.Index(text, trimmed)
+				points = append(points, xmlInjectionPoint{name: element, start: start, end: start + len(trimmed)})
+			}
+			i += end
+			continue
+		}
+
+		closer := ">"
+		switch {
+		case strings.HasPrefix(data[i:], "<!--"):
+			closer = "-->"
+		case strings.HasPrefix(data[i:], "<![CDATA["):
+			closer = "]]>"
+		case strings.HasPrefix(data[i:], "<?"):
+			closer = "?>"
+		}
+		end := strings.Index(data[i:], closer)
+		if end == -1 {
+			break
+		}
+		end += len(closer)
+		tag := data[i : i+end]
+
+		if closer == ">" && !strings.HasPrefix(tag, "</") && !strings.HasPrefix(tag, "<!") {
+			if m := xmlTagNameRe.FindStringSubmatch(tag); m != nil {
+				element = localName(m[1])
+				for _, a := range xmlAttrRe.FindAllStringSubmatchIndex(tag, -1) {
+					attr := tag[a[2]:a[3]]
+					if attr == "xmlns" || strings.HasPrefix(attr, "xmlns:") {
+						continue
+					}
+					vs, ve := a[4], a[5]
+					if vs == -1 {
+						vs, ve = a[6], a[7]
+					}
+					points = append(points, xmlInjectionPoint{name: element + "@" + localName(attr), start: i + vs, end: i + ve})
+				}
+			}
+		}
+		i += end
+	}
+	return points
+}
+
+func localName(name string) string {
+	if i := strings.LastIndexByte(name, ':'); i != -1 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// generateXMLTargets injects the payload into each element text and
+// attribute value of an XML template. The payload is XML-escaped so that,
+// once the server parses the document, it sees the raw special characters.
+// Repeated names are numbered (item, item[2], ...).
+func generateXMLTargets(inputURL, data, payload string) []Target {
+	var escaped bytes.Buffer
+	xml.EscapeText(&escaped, []byte(payload))
+
+	seen := make(map[string]int)
+	var targets []Target
+	for _, p := range xmlInjectionPoints(data) {
+		seen[p.name]++
+		name := p.name
+		if n := seen[p.name]; n > 1 {
+			name += "[" + strconv.Itoa(n) + "]"
+		}
+		targets = append(targets, Target{
+			URL:   inputURL,
+			Param: BodyParamPrefix + name,
+			Body:  data[:p.start] + escaped.String() + data[p.end:],
+		})
+	}
+	return targets
+}